@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// activationCodeFlag stocke la valeur du flag --code des commandes 'activate' et 'deactivate'.
+var activationCodeFlag string
+
+// ActivateCmd représente la commande 'activate'.
+var ActivateCmd = &cobra.Command{
+	Use:   "activate",
+	Short: "Réactive un lien court précédemment désactivé.",
+	Long: `Cette commande réactive un lien court : les visiteurs sont de nouveau redirigés
+vers l'URL longue.
+
+Exemple:
+  url-shortener activate --code="xyz123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLinkActive(true)
+	},
+}
+
+// DeactivateCmd représente la commande 'deactivate'.
+var DeactivateCmd = &cobra.Command{
+	Use:   "deactivate",
+	Short: "Désactive immédiatement un lien court sans supprimer son historique.",
+	Long: `Cette commande désactive un lien court : les visiteurs reçoivent la réponse
+"lien désactivé" configurée, mais le lien et ses clics sont conservés.
+
+Exemple:
+  url-shortener deactivate --code="xyz123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setLinkActive(false)
+	},
+}
+
+// setLinkActive applique le nouvel état d'activation au lien désigné par --code.
+func setLinkActive(active bool) {
+	// Valide la présence du flag --code.
+	if activationCodeFlag == "" {
+		fmt.Fprintf(os.Stderr, "Erreur: Le flag --code est requis\n")
+		os.Exit(1)
+	}
+
+	// Charge la configuration.
+	cfg := cmd2.Cfg
+	if cfg == nil {
+		log.Fatalf("FATAL: Configuration non chargée")
+	}
+
+	// Initialise la connexion à la base de données.
+	db, sqlDB := openDatabase(cfg)
+
+	// Assure la fermeture de la connexion à la fin de l'exécution.
+	defer sqlDB.Close()
+
+	// Initialise les repositories et le service.
+	linkRepo := repository.NewLinkRepository(db)
+	clickRepo := repository.NewClickRepository(db)
+	linkService := services.NewLinkService(linkRepo, clickRepo)
+
+	link, err := linkService.SetLinkActive(context.Background(), activationCodeFlag, active)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Fprintf(os.Stderr, "Erreur: Aucun lien trouvé avec le code: %s\n", activationCodeFlag)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Erreur lors de la mise à jour du lien: %v\n", err)
+		os.Exit(1)
+	}
+
+	if link.IsActive {
+		fmt.Printf("Le lien %s est maintenant actif.\n", link.ShortCode)
+	} else {
+		fmt.Printf("Le lien %s est maintenant désactivé.\n", link.ShortCode)
+	}
+}
+
+// init configure les commandes activate et deactivate, leurs flags, et les ajoute à la commande racine.
+func init() {
+	for _, c := range []*cobra.Command{ActivateCmd, DeactivateCmd} {
+		c.Flags().StringVar(&activationCodeFlag, "code", "", "Code court du lien à modifier")
+		c.MarkFlagRequired("code")
+		cmd2.RootCmd.AddCommand(c)
+	}
+}
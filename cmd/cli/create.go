@@ -1,30 +1,51 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // longURLFlag stocke la valeur du flag --url.
 var longURLFlag string
 
+// aliasFlag stocke la valeur du flag --alias.
+var aliasFlag string
+
+// expiresAtFlag, expiresInFlag et maxClicksFlag stockent les paramètres d'expiration du lien.
+var (
+	expiresAtFlag string
+	expiresInFlag time.Duration
+	maxClicksFlag int
+)
+
+// fallbackURLFlag et failoverFlag stockent le comportement de secours du lien.
+var (
+	fallbackURLFlag string
+	failoverFlag    string
+)
+
 // CreateCmd représente la commande 'create'.
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Crée une URL courte à partir d'une URL longue.",
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
-Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+Exemples:
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com/promo" --alias="spring-sale"
+  url-shortener create --url="https://example.com/promo" --expires-in=72h --max-clicks=500
+  url-shortener create --url="https://example.com/promo" --fallback-url="https://example.com" --failover=redirect_fallback`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Cobra gère la présence du flag avec MarkFlagRequired, mais une vérification manuelle est conservée.
 		if longURLFlag == "" {
@@ -37,6 +58,37 @@ Exemple:
 			fmt.Fprintf(os.Stderr, "Erreur: URL invalide: %v\n", err)
 			os.Exit(1)
 		}
+		// Valide l'alias avant d'ouvrir la base de données.
+		if aliasFlag != "" {
+			if err := services.ValidateAlias(aliasFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur: Alias invalide: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// Valide le comportement de secours.
+		if failoverFlag != "" || fallbackURLFlag != "" {
+			policy := failoverFlag
+			if policy == "" {
+				policy = models.FailoverRedirectFallback
+			}
+			if err := services.ValidateFailover(policy, fallbackURLFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur: Comportement de secours invalide: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		// Calcule la date d'expiration éventuelle.
+		var expiresAt *time.Time
+		if expiresAtFlag != "" {
+			t, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur: Date d'expiration invalide (format RFC3339 attendu): %v\n", err)
+				os.Exit(1)
+			}
+			expiresAt = &t
+		} else if expiresInFlag > 0 {
+			t := time.Now().Add(expiresInFlag)
+			expiresAt = &t
+		}
 		// Charge la configuration.
 		cfg := cmd2.Cfg
 		if cfg == nil {
@@ -44,14 +96,7 @@ Exemple:
 		}
 
 		// Initialise la connexion à la base de données.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
-		}
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
+		db, sqlDB := openDatabase(cfg)
 
 		// Assure la fermeture de la connexion à la fin de l'exécution.
 		defer sqlDB.Close()
@@ -60,8 +105,19 @@ Exemple:
 		clickRepo := repository.NewClickRepository(db)
 		linkService := services.NewLinkService(linkRepo, clickRepo)
 		// Crée le lien court.
-		link, err := linkService.CreateLink(longURLFlag)
+		link, err := linkService.CreateLink(context.Background(), longURLFlag, services.CreateLinkOptions{
+			Alias:     aliasFlag,
+			ExpiresAt: expiresAt,
+			MaxClicks: maxClicksFlag,
+
+			FallbackURL:    fallbackURLFlag,
+			FailoverPolicy: failoverFlag,
+		})
 		if err != nil {
+			if errors.Is(err, services.ErrAliasTaken) {
+				fmt.Fprintf(os.Stderr, "Erreur: L'alias '%s' est déjà utilisé\n", aliasFlag)
+				os.Exit(1)
+			}
 			fmt.Fprintf(os.Stderr, "Erreur lors de la création du lien: %v\n", err)
 			os.Exit(1)
 		}
@@ -70,12 +126,31 @@ Exemple:
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Clics autorisés: %d\n", link.MaxClicks)
+		}
+		if link.FailoverPolicy != models.FailoverKeep {
+			fmt.Printf("Si la destination est inaccessible: %s\n", link.FailoverPolicy)
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de secours: %s\n", link.FallbackURL)
+		}
 	},
 }
 
 // init configure la commande create, ses flags, et l'ajoute à la commande racine.
 func init() {
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&aliasFlag, "alias", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration du lien au format RFC3339 (optionnel)")
+	CreateCmd.Flags().DurationVar(&expiresInFlag, "expires-in", 0, "Durée de vie du lien, ex: 72h (optionnel)")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre maximal de clics avant épuisement du lien, 0 pour illimité")
+	CreateCmd.Flags().StringVar(&fallbackURLFlag, "fallback-url", "", "URL de secours si la destination est déclarée inaccessible, non vérifiée par le moniteur (optionnel)")
+	CreateCmd.Flags().StringVar(&failoverFlag, "failover", "", "Politique si la destination est déclarée inaccessible: keep, redirect_fallback ou unavailable_page (redirect_fallback si --fallback-url est fourni, keep sinon)")
+	CreateCmd.MarkFlagsMutuallyExclusive("expires-at", "expires-in")
 	CreateCmd.MarkFlagRequired("url")
 	cmd2.RootCmd.AddCommand(CreateCmd)
 }
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openDatabase ouvre la connexion à la base de données configurée et arrête le programme en cas d'échec.
+// L'appelant doit fermer la connexion SQL sous-jacente renvoyée.
+func openDatabase(cfg *config.Config) (*gorm.DB, *sql.DB) {
+	db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{Logger: logging.NewGormLogger()})
+	if err != nil {
+		log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+	return db, sqlDB
+}
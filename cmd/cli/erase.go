@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"text/tabwriter"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// Flags de la commande 'erase'.
+var (
+	eraseIPFlag          string
+	eraseVisitorHashFlag string
+	eraseReasonFlag      string
+	eraseListFlag        bool
+	eraseLimitFlag       int
+)
+
+// EraseCmd représente la commande 'erase'.
+var EraseCmd = &cobra.Command{
+	Use:   "erase",
+	Short: "Supprime les clics d'une personne sur demande d'effacement (RGPD).",
+	Long: `Cette commande supprime tous les clics correspondant à une adresse IP ou à une
+empreinte de visiteur, et enregistre un audit de la suppression. L'audit ne conserve
+pas l'identifiant, seulement le critère, le mode d'anonymisation et le nombre de clics supprimés.
+
+Les événements encore en attente dans le spool du serveur (analytics.spool) ne sont
+effacés que par l'API d'administration (DELETE /api/v1/admin/clicks), servie par le
+processus qui détient le spool.
+
+L'adresse IP est recherchée sous sa forme complète et sous sa forme anonymisée
+(analytics.ip_anonymization) : avec la troncature, les clics de tout le préfixe sont supprimés.
+
+Avec --list, elle affiche les derniers audits d'effacement.
+
+Exemples:
+  url-shortener erase --ip="203.0.113.42" --reason="DSR-2025-014"
+  url-shortener erase --visitor-hash="9f86d081..."
+  url-shortener erase --list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Charge la configuration.
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+		anonymizer, err := privacy.NewAnonymizer(cfg.Analytics.IPAnonymization, cfg.Analytics.IPHashKey)
+		if err != nil {
+			log.Fatalf("FATAL: Configuration d'anonymisation des IP invalide: %v", err)
+		}
+
+		// Initialise la connexion à la base de données.
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		// Le spool appartient au serveur : ses événements en attente ne sont effacés que par l'API d'administration.
+		privacyService := services.NewPrivacyService(repository.NewErasureRepository(db), anonymizer, nil, nil)
+
+		if eraseListFlag {
+			printErasures(privacyService)
+			return
+		}
+
+		audit, err := privacyService.EraseClicks(context.Background(), services.ErasureRequest{
+			IP:          eraseIPFlag,
+			VisitorHash: eraseVisitorHashFlag,
+			Actor:       cliActor(),
+			Reason:      eraseReasonFlag,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidErasureRequest) {
+				fmt.Fprintf(os.Stderr, "Erreur: Utilisez soit --ip, soit --visitor-hash (%v)\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur lors de la suppression des clics: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d clic(s) supprimé(s). Audit #%d enregistré.\n", audit.DeletedCount, audit.ID)
+	},
+}
+
+// printErasures affiche les derniers audits d'effacement.
+func printErasures(privacyService *services.PrivacyService) {
+	audits, err := privacyService.ListErasures(context.Background(), eraseLimitFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des audits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(audits) == 0 {
+		fmt.Println("Aucun effacement enregistré.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tCRITÈRE\tCLICS\tAUTEUR\tMOTIF")
+	for _, audit := range audits {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", audit.ID, audit.CreatedAt.Format("2006-01-02 15:04"),
+			audit.Criterion, audit.DeletedCount, audit.Actor, audit.Reason)
+	}
+	w.Flush()
+}
+
+// cliActor identifie l'utilisateur système à l'origine d'un effacement.
+func cliActor() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+// init configure la commande erase, ses flags, et l'ajoute à la commande racine.
+func init() {
+	EraseCmd.Flags().StringVar(&eraseIPFlag, "ip", "", "Adresse IP dont les clics doivent être supprimés")
+	EraseCmd.Flags().StringVar(&eraseVisitorHashFlag, "visitor-hash", "", "Empreinte de visiteur dont les clics doivent être supprimés")
+	EraseCmd.Flags().StringVar(&eraseReasonFlag, "reason", "", "Référence de la demande d'effacement, conservée dans l'audit")
+	EraseCmd.Flags().BoolVar(&eraseListFlag, "list", false, "Affiche les derniers audits d'effacement")
+	EraseCmd.Flags().IntVar(&eraseLimitFlag, "limit", 0, "Nombre d'audits affichés avec --list (50 par défaut)")
+	EraseCmd.MarkFlagsMutuallyExclusive("ip", "visitor-hash", "list")
+	cmd2.RootCmd.AddCommand(EraseCmd)
+}
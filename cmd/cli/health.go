@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// Flags de la commande 'health'.
+var (
+	healthCodeFlag string
+	healthDaysFlag int
+)
+
+// HealthCmd représente la commande 'health'.
+var HealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Affiche l'état de santé de l'URL longue d'un lien court et son historique.",
+	Long: `Cette commande affiche l'état de santé constaté par le moniteur d'URLs pour un lien court,
+son taux de disponibilité sur la période et ses derniers changements d'état.
+
+Exemples:
+  url-shortener health --code="xyz123"
+  url-shortener health --code="xyz123" --days=30`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Valide la présence du flag --code.
+		if healthCodeFlag == "" {
+			fmt.Fprintf(os.Stderr, "Erreur: Le flag --code est requis\n")
+			os.Exit(1)
+		}
+
+		// Charge la configuration.
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+
+		// Initialise la connexion à la base de données.
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		linkHealthService := services.NewLinkHealthService(repository.NewLinkRepository(db), repository.NewLinkCheckRepository(db))
+		link, report, err := linkHealthService.GetLinkHealth(context.Background(), healthCodeFlag, healthDaysFlag)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Fprintf(os.Stderr, "Erreur: Aucun lien trouvé avec le code: %s\n", healthCodeFlag)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de l'état de santé: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("État de santé pour le code court: %s\n", link.ShortCode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		fmt.Printf("État actuel: %s\n", report.Status)
+		if report.LastCheckedAt != nil {
+			fmt.Printf("Dernière vérification: %s\n", report.LastCheckedAt.Format("2006-01-02 15:04:05"))
+		}
+		if report.ConsecutiveFailures > 0 {
+			fmt.Printf("Échecs consécutifs: %d\n", report.ConsecutiveFailures)
+		}
+		if report.Checks == 0 {
+			fmt.Printf("\nAucune vérification sur les %d derniers jours.\n", report.Days)
+			return
+		}
+		if last := report.LastCheck; last != nil && len(last.RedirectChain) > 0 {
+			fmt.Printf("Redirections suivies: %s\n", strings.Join(last.RedirectChain, " -> "))
+		}
+		fmt.Printf("\nSur les %d derniers jours: %d vérification(s), disponibilité %.2f %%, latence moyenne %d ms\n",
+			report.Days, report.Checks, *report.UptimePercent, report.AvgLatencyMs)
+
+		if len(report.Transitions) == 0 {
+			fmt.Println("Aucun changement d'état sur la période.")
+			return
+		}
+		fmt.Println("\nDerniers changements d'état:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  DATE\tDE\tVERS\tCODE HTTP\tERREUR")
+		for _, t := range report.Transitions {
+			code := "-"
+			if t.StatusCode != 0 {
+				code = fmt.Sprint(t.StatusCode)
+			}
+			errorClass := t.ErrorClass
+			if errorClass == "" {
+				errorClass = "-"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", t.At.Format("2006-01-02 15:04:05"), t.From, t.To, code, errorClass)
+		}
+		w.Flush()
+	},
+}
+
+// init configure la commande health, ses flags, et l'ajoute à la commande racine.
+func init() {
+	HealthCmd.Flags().StringVar(&healthCodeFlag, "code", "", "Code court du lien à consulter")
+	HealthCmd.Flags().IntVar(&healthDaysFlag, "days", 7, "Nombre de jours d'historique pris en compte (90 maximum)")
+	HealthCmd.MarkFlagRequired("code")
+	cmd2.RootCmd.AddCommand(HealthCmd)
+}
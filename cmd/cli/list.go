@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// Flags de la commande 'list'.
+var (
+	listFromFlag   string
+	listToFlag     string
+	listActiveFlag bool
+	listDomainFlag string
+	listSearchFlag string
+	listSortFlag   string
+	listOrderFlag  string
+	listCursorFlag string
+	listLimitFlag  int
+)
+
+// ListCmd représente la commande 'list'.
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts avec pagination, filtres et tri.",
+	Long: `Cette commande affiche une page de liens courts. Utilisez le curseur affiché
+en fin de liste avec --cursor pour obtenir la page suivante.
+
+Exemples:
+  url-shortener list --limit=50
+  url-shortener list --domain="example.com" --active=true --sort=clicks
+  url-shortener list --from=2025-01-01 --to=2025-02-01 --search="promo"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		input := services.ListLinksInput{
+			Domain: listDomainFlag,
+			Search: listSearchFlag,
+			SortBy: listSortFlag,
+			Order:  listOrderFlag,
+			Cursor: listCursorFlag,
+			Limit:  listLimitFlag,
+		}
+		if listFromFlag != "" {
+			from, err := parseDateFlag(listFromFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur: Date --from invalide: %v\n", err)
+				os.Exit(1)
+			}
+			input.CreatedFrom = &from
+		}
+		if listToFlag != "" {
+			to, err := parseDateFlag(listToFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur: Date --to invalide: %v\n", err)
+				os.Exit(1)
+			}
+			input.CreatedTo = &to
+		}
+		// Le filtre d'état ne s'applique que si le flag est explicitement fourni.
+		if cmd.Flags().Changed("active") {
+			input.IsActive = &listActiveFlag
+		}
+
+		// Charge la configuration.
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+
+		// Initialise la connexion à la base de données.
+		db, sqlDB := openDatabase(cfg)
+
+		// Assure la fermeture de la connexion à la fin de l'exécution.
+		defer sqlDB.Close()
+
+		// Initialise les repositories et le service.
+		linkRepo := repository.NewLinkRepository(db)
+		clickRepo := repository.NewClickRepository(db)
+		linkService := services.NewLinkService(linkRepo, clickRepo)
+
+		page, err := linkService.ListLinks(context.Background(), input)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListQuery) {
+				fmt.Fprintf(os.Stderr, "Erreur: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des liens: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(page.Links) == 0 {
+			fmt.Println("Aucun lien trouvé.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tACTIF\tCLICS\tCRÉÉ LE\tURL LONGUE")
+		for _, link := range page.Links {
+			fmt.Fprintf(w, "%s\t%t\t%d\t%s\t%s\n",
+				link.ShortCode, link.IsActive, link.ClickCount, link.CreatedAt.Format("2006-01-02 15:04"), link.LongURL)
+		}
+		w.Flush()
+
+		if page.NextCursor != "" {
+			fmt.Printf("\nPage suivante: --cursor=%s\n", page.NextCursor)
+		}
+	},
+}
+
+// parseDateFlag accepte une date au format RFC3339 ou AAAA-MM-JJ (heure locale).
+func parseDateFlag(value string) (time.Time, error) {
+	return services.ParseTimeBound(value, time.Local)
+}
+
+// init configure la commande list, ses flags, et l'ajoute à la commande racine.
+func init() {
+	ListCmd.Flags().StringVar(&listFromFlag, "from", "", "Ne liste que les liens créés à partir de cette date (RFC3339 ou AAAA-MM-JJ)")
+	ListCmd.Flags().StringVar(&listToFlag, "to", "", "Ne liste que les liens créés avant cette date (RFC3339 ou AAAA-MM-JJ)")
+	ListCmd.Flags().BoolVar(&listActiveFlag, "active", true, "Filtre sur l'état d'activation des liens")
+	ListCmd.Flags().StringVar(&listDomainFlag, "domain", "", "Filtre sur le domaine de l'URL longue")
+	ListCmd.Flags().StringVar(&listSearchFlag, "search", "", "Filtre sur une sous-chaîne de l'URL longue")
+	ListCmd.Flags().StringVar(&listSortFlag, "sort", repository.SortByCreated, "Critère de tri: created ou clicks")
+	ListCmd.Flags().StringVar(&listOrderFlag, "order", "desc", "Ordre de tri: asc ou desc")
+	ListCmd.Flags().StringVar(&listCursorFlag, "cursor", "", "Curseur de la page à afficher")
+	ListCmd.Flags().IntVar(&listLimitFlag, "limit", 20, "Nombre de liens par page (100 maximum)")
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
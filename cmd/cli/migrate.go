@@ -1,14 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 // MigrateCmd représente la commande 'migrate'.
@@ -25,22 +26,55 @@ basées sur les modèles Go.`,
 			log.Fatalf("FATAL: Configuration non chargée")
 		}
 		// Initialise la connexion à la base de données.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
-		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
+		db, sqlDB := openDatabase(cfg)
 		// Assure la fermeture de la connexion après la migration.
 		defer sqlDB.Close()
 		// Exécute les migrations automatiques.
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		err := db.AutoMigrate(&models.Link{}, &models.Click{}, &models.VisitorSalt{},
+			&models.ClickRollup{}, &models.ClickDimensionRollup{}, &models.ErasureAudit{}, &models.LinkCheck{},
+			&models.VisitorSketch{})
 		if err != nil {
 			log.Fatalf("FATAL: Échec des migrations: %v", err)
 		}
+		// Supprime les empreintes d'identifiants des anciens audits d'effacement, réversibles pour les adresses IP.
+		if db.Migrator().HasColumn(&models.ErasureAudit{}, "subject_hash") {
+			if err := db.Migrator().DropColumn(&models.ErasureAudit{}, "subject_hash"); err != nil {
+				log.Fatalf("FATAL: Échec de la suppression des empreintes des audits d'effacement: %v", err)
+			}
+		}
+		// Efface le User-Agent brut des clics déjà dotés d'une empreinte de visiteur.
+		err = db.Model(&models.Click{}).Where("visitor_hash <> '' AND user_agent <> ''").Update("user_agent", "").Error
+		if err != nil {
+			log.Fatalf("FATAL: Échec de l'effacement des User-Agents bruts: %v", err)
+		}
+		// Applique l'anonymisation configurée aux adresses IP des clics déjà enregistrés.
+		anonymizer, err := privacy.NewAnonymizer(cfg.Analytics.IPAnonymization, cfg.Analytics.IPHashKey)
+		if err != nil {
+			log.Fatalf("FATAL: Configuration d'anonymisation des IP invalide: %v", err)
+		}
+		if anonymizer.Mode() != privacy.ModeNone {
+			count, err := repository.NewClickRepository(db).RewriteIPAddresses(context.Background(), anonymizer.AnonymizeStored)
+			if err != nil {
+				log.Fatalf("FATAL: Échec de l'anonymisation des adresses IP enregistrées: %v", err)
+			}
+			if count > 0 {
+				fmt.Printf("Adresses IP anonymisées: %d clic(s)\n", count)
+			}
+		}
+		// Renseigne l'hôte des liens existants, utilisé par le filtre par domaine.
+		if _, err := repository.NewLinkRepository(db).BackfillLinkHosts(context.Background()); err != nil {
+			log.Fatalf("FATAL: Échec du calcul des hôtes des liens: %v", err)
+		}
+		// Calcule les sketches de visiteurs des clics enregistrés avant l'activation de l'estimation.
+		if cfg.Analytics.UniqueVisitorsHLLThreshold > 0 {
+			count, err := repository.NewVisitorSketchRepository(db).BackfillVisitorSketches(context.Background())
+			if err != nil {
+				log.Fatalf("FATAL: Échec du calcul des sketches de visiteurs: %v", err)
+			}
+			if count > 0 {
+				fmt.Printf("Sketches de visiteurs calculés: %d\n", count)
+			}
+		}
 
 		fmt.Println("Migrations de la base de données exécutées avec succès.")
 	},
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"github.com/spf13/cobra"
+)
+
+// rollupDaysFlag stocke la valeur du flag --days.
+var rollupDaysFlag int
+
+// RollupCmd représente la commande 'rollup'.
+var RollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Agrège puis purge les clics bruts plus anciens que la fenêtre de rétention.",
+	Long: `Cette commande exécute immédiatement le job de rétention : les clics bruts antérieurs
+à la fenêtre de rétention sont agrégés par lien et par jour, puis supprimés.
+Les statistiques restent identiques, mais l'heure exacte des clics purgés est perdue.
+
+Sans --days, la valeur analytics.retention.raw_click_days de la configuration est utilisée.
+
+Exemples:
+  url-shortener rollup
+  url-shortener rollup --days=90`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Charge la configuration.
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+		days := cfg.Analytics.Retention.RawClickDays
+		if cmd.Flags().Changed("days") {
+			days = rollupDaysFlag
+		}
+		if days <= 0 {
+			fmt.Fprintf(os.Stderr, "Erreur: Aucune fenêtre de rétention configurée, utilisez --days\n")
+			os.Exit(1)
+		}
+
+		// Initialise la connexion à la base de données.
+		db, sqlDB := openDatabase(cfg)
+		defer sqlDB.Close()
+
+		job := workers.NewRetentionJob(repository.NewClickRepository(db), days, 0)
+		purged, err := job.RunOnce(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'agrégation des clics: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d clic(s) brut(s) de plus de %d jour(s) agrégé(s) et purgé(s).\n", purged, days)
+	},
+}
+
+// init configure la commande rollup et l'ajoute à la commande racine.
+func init() {
+	RollupCmd.Flags().IntVar(&rollupDaysFlag, "days", 0, "Nombre de jours de clics bruts à conserver")
+	cmd2.RootCmd.AddCommand(RollupCmd)
+}
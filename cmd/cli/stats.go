@@ -1,23 +1,38 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
 
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 // shortCodeFlag stocke la valeur du flag --code.
 var shortCodeFlag string
 
+// Flags du mode série temporelle de la commande 'stats'.
+var (
+	statsFromFlag      string
+	statsToFlag        string
+	statsIntervalFlag  string
+	statsTimezoneFlag  string
+	statsSparklineFlag bool
+)
+
+// sparklineLevels sont les caractères utilisés pour dessiner une sparkline, du plus bas au plus haut.
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
 // StatsCmd représente la commande 'stats'.
 var StatsCmd = &cobra.Command{
 	Use:   "stats",
@@ -25,8 +40,13 @@ var StatsCmd = &cobra.Command{
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
 pour une URL courte spécifique en utilisant son code.
 
-Exemple:
-  url-shortener stats --code="xyz123"`,
+Avec --from, --to ou --interval, elle affiche la répartition des clics
+par heure, jour ou semaine, sous forme de tableau ou de sparkline.
+
+Exemples:
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --from=2025-01-01 --to=2025-02-01 --interval=day
+  url-shortener stats --code="xyz123" --interval=hour --tz="Europe/Paris" --sparkline`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Valide la présence du flag --code.
 		if shortCodeFlag == "" {
@@ -41,15 +61,7 @@ Exemple:
 		}
 
 		// Initialise la connexion à la base de données.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
-		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
-		}
-
-		sqlDB, err := db.DB()
-		if err != nil {
-			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
-		}
+		db, sqlDB := openDatabase(cfg)
 
 		// Assure la fermeture de la connexion à la fin de l'exécution.
 		defer sqlDB.Close()
@@ -58,9 +70,16 @@ Exemple:
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
 		linkService := services.NewLinkService(linkRepo, clickRepo)
+		linkService.SetApproximateUniques(cfg.Analytics.UniqueVisitorsHLLThreshold, repository.NewVisitorSketchRepository(db))
+
+		// Bascule en mode série temporelle si l'un de ses flags est fourni.
+		if cmd.Flags().Changed("from") || cmd.Flags().Changed("to") || cmd.Flags().Changed("interval") {
+			printTimeSeries(linkService)
+			return
+		}
 
 		// Récupère le lien et ses statistiques.
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, stats, err := linkService.GetLinkStats(context.Background(), shortCodeFlag)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				fmt.Fprintf(os.Stderr, "Erreur: Aucun lien trouvé avec le code: %s\n", shortCodeFlag)
@@ -72,13 +91,148 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
-		fmt.Printf("Total de clics: %d\n", totalClicks)
+		fmt.Printf("Total de clics: %d\n", stats.TotalClicks)
+		printUniqueVisitors(stats)
+		printBreakdowns(stats.Breakdowns)
 	},
 }
 
+// printUniqueVisitors affiche le total des visiteurs uniques et leur détail par jour.
+func printUniqueVisitors(stats *services.LinkStats) {
+	suffix := ""
+	if stats.UniqueVisitorsApproximate {
+		suffix = " (estimation)"
+	}
+	fmt.Printf("Visiteurs uniques (cumul quotidien): %d%s\n", stats.UniqueVisitors, suffix)
+	if len(stats.UniqueVisitorsByDay) == 0 {
+		return
+	}
+	fmt.Println("\nVisiteurs uniques par jour (UTC):")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, day := range stats.UniqueVisitorsByDay {
+		fmt.Fprintf(w, "  %s\t%d\n", day.Day, day.Visitors)
+	}
+	w.Flush()
+}
+
+// breakdownTitles associe chaque dimension ventilée à son titre affiché, dans l'ordre d'affichage.
+// Le champ empty est le libellé affiché pour une valeur vide.
+var breakdownTitles = []struct {
+	dimension string
+	title     string
+	empty     string
+}{
+	{repository.DimensionBrowser, "Navigateurs", "(inconnu)"},
+	{repository.DimensionOS, "Systèmes d'exploitation", "(inconnu)"},
+	{repository.DimensionDevice, "Appareils", "(inconnu)"},
+	{repository.DimensionBot, "Robots / humains", "(inconnu)"},
+	{repository.DimensionReferrer, "Top référents", "(accès direct)"},
+	{repository.DimensionReferrerDomain, "Top domaines référents", "(accès direct)"},
+	{repository.DimensionCountry, "Pays", "(inconnu)"},
+	{repository.DimensionRegion, "Régions", "(inconnue)"},
+	{repository.DimensionCity, "Villes", "(inconnue)"},
+}
+
+// printBreakdowns affiche la ventilation des clics pour chaque dimension disponible.
+func printBreakdowns(breakdowns map[string][]models.BreakdownEntry) {
+	for _, bt := range breakdownTitles {
+		entries, ok := breakdowns[bt.dimension]
+		if !ok || len(entries) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", bt.title)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, entry := range entries {
+			value := entry.Value
+			if value == "" {
+				value = bt.empty
+			}
+			fmt.Fprintf(w, "  %s\t%d\n", value, entry.Clicks)
+		}
+		w.Flush()
+	}
+}
+
+// printTimeSeries affiche la répartition temporelle des clics du lien demandé.
+func printTimeSeries(linkService *services.LinkService) {
+	loc, err := time.LoadLocation(statsTimezoneFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur: Fuseau horaire invalide: %v\n", err)
+		os.Exit(1)
+	}
+	to := time.Now()
+	if statsToFlag != "" {
+		if to, err = services.ParseTimeBound(statsToFlag, loc); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: Date --to invalide: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	from := to.AddDate(0, 0, -7)
+	if statsFromFlag != "" {
+		if from, err = services.ParseTimeBound(statsFromFlag, loc); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: Date --from invalide: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	link, series, err := linkService.GetLinkTimeSeries(context.Background(), shortCodeFlag, from, to, statsIntervalFlag, loc)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Fprintf(os.Stderr, "Erreur: Aucun lien trouvé avec le code: %s\n", shortCodeFlag)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des statistiques: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
+	fmt.Printf("URL longue: %s\n", link.LongURL)
+	fmt.Printf("Période: %s → %s (%s, par %s)\n",
+		series.From.In(loc).Format(time.RFC3339), series.To.In(loc).Format(time.RFC3339), series.Timezone, series.Interval)
+	fmt.Printf("Total de clics sur la période: %d\n\n", series.Total)
+
+	if statsSparklineFlag {
+		fmt.Println(sparkline(series.Buckets))
+		return
+	}
+
+	layout := "2006-01-02"
+	if series.Interval == services.IntervalHour {
+		layout = "2006-01-02 15:04"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DÉBUT\tCLICS")
+	for _, bucket := range series.Buckets {
+		fmt.Fprintf(w, "%s\t%d\n", bucket.Start.Format(layout), bucket.Clicks)
+	}
+	w.Flush()
+}
+
+// sparkline dessine une série de clics sur une ligne, proportionnellement au maximum.
+func sparkline(buckets []services.TimeSeriesBucket) string {
+	maxClicks := 0
+	for _, bucket := range buckets {
+		maxClicks = max(maxClicks, bucket.Clicks)
+	}
+	line := make([]rune, len(buckets))
+	for i, bucket := range buckets {
+		level := 0
+		if maxClicks > 0 {
+			level = bucket.Clicks * (len(sparklineLevels) - 1) / maxClicks
+		}
+		line[i] = sparklineLevels[level]
+	}
+	return string(line)
+}
+
 // init configure la commande stats, ses flags, et l'ajoute à la commande racine.
 func init() {
 	StatsCmd.Flags().StringVar(&shortCodeFlag, "code", "", "Code court pour lequel récupérer les statistiques")
+	StatsCmd.Flags().StringVar(&statsFromFlag, "from", "", "Début de la période (RFC3339 ou AAAA-MM-JJ), 7 jours avant --to par défaut")
+	StatsCmd.Flags().StringVar(&statsToFlag, "to", "", "Fin de la période (RFC3339 ou AAAA-MM-JJ), maintenant par défaut")
+	StatsCmd.Flags().StringVar(&statsIntervalFlag, "interval", services.IntervalDay, "Intervalle d'agrégation: hour, day ou week")
+	StatsCmd.Flags().StringVar(&statsTimezoneFlag, "tz", "UTC", "Fuseau horaire des intervalles, ex: Europe/Paris (UTC par défaut, comme l'API)")
+	StatsCmd.Flags().BoolVar(&statsSparklineFlag, "sparkline", false, "Affiche la série sous forme de sparkline plutôt que de tableau")
 	StatsCmd.MarkFlagRequired("code")
 	cmd2.RootCmd.AddCommand(StatsCmd)
 }
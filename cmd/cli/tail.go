@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// tailCodeFlag stocke la valeur du flag --code de la commande 'tail'.
+var tailCodeFlag string
+
+// TailCmd représente la commande 'tail'.
+var TailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Affiche en temps réel les clics reçus par le serveur.",
+	Long: `Cette commande se connecte au flux Server-Sent Events du serveur (server.base_url)
+et affiche chaque clic dès son traitement. Sans --code, les clics de tous les liens
+sont affichés. Interrompez-la avec Ctrl+C.
+
+Le flux est protégé par le jeton d'administration (server.admin_token), lu dans la configuration.
+
+Exemples:
+  url-shortener tail --code="xyz123"
+  url-shortener tail`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Charge la configuration.
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalf("FATAL: Configuration non chargée")
+		}
+
+		if cfg.Server.AdminToken == "" {
+			fmt.Fprintf(os.Stderr, "Erreur: Le flux de clics exige un jeton d'administration (server.admin_token)\n")
+			os.Exit(1)
+		}
+
+		streamURL := strings.TrimSuffix(cfg.Server.BaseURL, "/") + "/api/v1/events"
+		if tailCodeFlag != "" {
+			streamURL = strings.TrimSuffix(cfg.Server.BaseURL, "/") + "/api/v1/links/" + url.PathEscape(tailCodeFlag) + "/events"
+		}
+
+		req, err := http.NewRequest(http.MethodGet, streamURL, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: URL du serveur invalide: %v\n", err)
+			os.Exit(1)
+		}
+		req.Header.Set("Authorization", "Bearer "+cfg.Server.AdminToken)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: Impossible de se connecter au serveur: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			fmt.Fprintf(os.Stderr, "Erreur: Jeton d'administration refusé par le serveur\n")
+			os.Exit(1)
+		}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			fmt.Fprintf(os.Stderr, "Erreur: Les flux de clics sont désactivés sur le serveur (server.admin_token non configuré)\n")
+			os.Exit(1)
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			fmt.Fprintf(os.Stderr, "Erreur: Aucun lien trouvé avec le code: %s\n", tailCodeFlag)
+			os.Exit(1)
+		}
+		if resp.StatusCode != http.StatusOK {
+			fmt.Fprintf(os.Stderr, "Erreur: Réponse inattendue du serveur: %s\n", resp.Status)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(os.Stderr, "Connecté à %s, en attente de clics...\n", streamURL)
+		if err := readClickStream(resp); err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur: Lecture du flux interrompue: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Flux fermé par le serveur.")
+	},
+}
+
+// readClickStream lit les événements SSE de la réponse et affiche une ligne par clic.
+func readClickStream(resp *http.Response) error {
+	scanner := bufio.NewScanner(resp.Body)
+	var event string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Une ligne vide termine l'événement en cours.
+			printStreamEvent(event, data.String())
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	return scanner.Err()
+}
+
+// printStreamEvent affiche un événement du flux de clics.
+func printStreamEvent(event, data string) {
+	switch event {
+	case "click":
+		var record api.StreamClick
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			fmt.Fprintf(os.Stderr, "Attention: Clic illisible: %v\n", err)
+			return
+		}
+		referrer := record.ReferrerHost
+		if referrer == "" {
+			referrer = "(accès direct)"
+		}
+		fmt.Printf("%s  lien=%d  %s  %s  %s\n",
+			record.Timestamp.Local().Format(time.DateTime), record.LinkID,
+			orUnknown(record.DeviceType), orUnknown(record.Country), referrer)
+	case "dropped":
+		fmt.Fprintln(os.Stderr, "Attention: Le serveur a fermé le flux, l'affichage ne suivait pas le rythme des clics.")
+	}
+}
+
+// orUnknown remplace une valeur vide par un libellé explicite.
+func orUnknown(value string) string {
+	if value == "" {
+		return "?"
+	}
+	return value
+}
+
+// init configure la commande tail, ses flags, et l'ajoute à la commande racine.
+func init() {
+	TailCmd.Flags().StringVar(&tailCodeFlag, "code", "", "Code court dont les clics doivent être suivis (tous les liens si vide)")
+	cmd2.RootCmd.AddCommand(TailCmd)
+}
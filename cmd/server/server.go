@@ -1,9 +1,10 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,10 +13,18 @@ import (
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/broker"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/notify"
+	"github.com/axellelanca/urlshortener/internal/privacy"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/sinks"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -35,38 +44,170 @@ puis lance le serveur HTTP.`,
 		// Charge la configuration.
 		cfg := cmd2.Cfg
 		if cfg == nil {
-			log.Fatalf("FATAL: Configuration non chargée")
+			fatal("configuration not loaded")
 		}
+		// Configure la journalisation structurée ; le paquet log standard est également redirigé vers slog.
+		logger, err := logging.New(os.Stderr, cfg.Logging.Format, cfg.Logging.Level)
+		if err != nil {
+			fatal("invalid logging configuration", logging.Err(err))
+		}
+		slog.SetDefault(logger)
 		// Initialise la connexion à la base de données.
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{Logger: logging.NewGormLogger()})
+		if err != nil {
+			fatal("failed to connect to database", logging.Err(err))
+		}
+		sqlDB, err := db.DB()
 		if err != nil {
-			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
+			fatal("failed to get underlying sql database", logging.Err(err))
 		}
 		// Initialise les repositories.
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
-		log.Println("Repositories initialisés.")
+		saltRepo := repository.NewVisitorSaltRepository(db)
+		erasureRepo := repository.NewErasureRepository(db)
+		checkRepo := repository.NewLinkCheckRepository(db)
+		sketchRepo := repository.NewVisitorSketchRepository(db)
 
 		// Initialise les services métiers.
 		linkService := services.NewLinkService(linkRepo, clickRepo)
-		log.Println("Services métiers initialisés.")
+		linkService.SetApproximateUniques(cfg.Analytics.UniqueVisitorsHLLThreshold, sketchRepo)
+		anonymizer, err := privacy.NewAnonymizer(cfg.Analytics.IPAnonymization, cfg.Analytics.IPHashKey)
+		if err != nil {
+			fatal("invalid ip anonymization configuration", logging.Err(err))
+		}
+		linkHealthService := services.NewLinkHealthService(linkRepo, checkRepo)
 
 		// Initialise le channel des événements de clic et lance les workers.
 		clickEventsChannel := make(chan models.ClickEvent, cfg.Analytics.BufferSize)
-		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo)
-		log.Printf("Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
+		metrics.RegisterClickChannel(clickEventsChannel)
+		visitorHasher := workers.NewVisitorHasher(saltRepo)
+		enrichers := []workers.Enricher{visitorHasher}
+		if cfg.Analytics.GeoIPDatabase != "" {
+			geoEnricher, err := workers.NewGeoEnricher(cfg.Analytics.GeoIPDatabase)
+			if err != nil {
+				slog.Warn("click geolocation disabled", logging.Err(err))
+			} else {
+				defer geoEnricher.Close()
+				enrichers = append(enrichers, geoEnricher)
+				slog.Info("click geolocation enabled", "database", cfg.Analytics.GeoIPDatabase)
+			}
+		}
+		// L'anonymisation des IP passe en dernier : les enrichers précédents utilisent l'adresse complète.
+		enrichers = append(enrichers, workers.NewIPAnonymizer(anonymizer))
+		slog.Info("click ip anonymization configured", "mode", anonymizer.Mode())
+		// Ouvre les sinks d'export des clics configurés.
+		var clickSink sinks.Sink
+		if len(cfg.Analytics.Sinks) > 0 {
+			clickSinks, err := sinks.FromConfig(cfg.Analytics.Sinks)
+			if err != nil {
+				fatal("failed to open click sinks", logging.Err(err))
+			}
+			clickSink = clickSinks
+			slog.Info("click export sinks configured", "sinks", len(clickSinks))
+		}
+		// Diffuse les clics traités aux abonnés du flux temps réel.
+		clickBroker := broker.New(cfg.Analytics.StreamBufferSize)
+		workerOpts := workers.Options{
+			BatchSize:     cfg.Analytics.BatchSize,
+			FlushInterval: time.Duration(cfg.Analytics.FlushIntervalMs) * time.Millisecond,
+			Enrichers:     enrichers,
+			Sink:          clickSink,
+			Publisher:     clickBroker,
+		}
+		// Les sketches de visiteurs ne sont tenus que si l'estimation est activée.
+		if cfg.Analytics.UniqueVisitorsHLLThreshold > 0 {
+			workerOpts.VisitorSketches = sketchRepo
+		}
+		clickWorkers := workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEventsChannel, clickRepo, workerOpts)
+
+		// Contextes des tâches de fond, annulés à l'arrêt du serveur.
+		replayCtx, cancelReplay := context.WithCancel(context.Background())
+		defer cancelReplay()
+		monitorCtx, cancelMonitor := context.WithCancel(context.Background())
+		defer cancelMonitor()
+
+		// Ouvre le spool sur disque et rejoue les clics en attente, y compris ceux d'une exécution précédente.
+		var clickSpool *spool.Spool
+		replayDone := make(chan struct{})
+		if cfg.Analytics.Spool.Enabled {
+			syncInterval := time.Duration(cfg.Analytics.Spool.SyncIntervalMs) * time.Millisecond
+			clickSpool, err = spool.Open(cfg.Analytics.Spool.Dir, int64(cfg.Analytics.Spool.MaxSegmentMB)<<20, syncInterval)
+			if err != nil {
+				fatal("failed to open click spool", logging.Err(err))
+			}
+			replayInterval := time.Duration(cfg.Analytics.Spool.ReplayIntervalMs) * time.Millisecond
+			go func() {
+				defer close(replayDone)
+				clickSpool.Replay(replayCtx, clickEventsChannel, replayInterval)
+			}()
+			slog.Info("click spool enabled", "dir", cfg.Analytics.Spool.Dir)
+		}
+		// Les demandes d'effacement portent aussi sur les événements du spool, qui contiennent l'adresse IP complète.
+		privacyService := services.NewPrivacyService(erasureRepo, anonymizer, clickSpool, visitorHasher.Hash)
+
+		// Lance l'agrégation et la purge périodiques des clics bruts anciens.
+		retentionDone := make(chan struct{})
+		if days := cfg.Analytics.Retention.RawClickDays; days > 0 {
+			rollupInterval := time.Duration(cfg.Analytics.Retention.RollupIntervalMinutes) * time.Minute
+			retentionJob := workers.NewRetentionJob(clickRepo, days, rollupInterval)
+			go func() {
+				defer close(retentionDone)
+				retentionJob.Start(monitorCtx)
+			}()
+		} else {
+			close(retentionDone)
+		}
+
+		// Prépare l'envoi des changements d'état des liens vers les canaux configurés.
+		var stateNotifier *notify.Dispatcher
+		if len(cfg.Monitor.Notifications.Channels) > 0 {
+			channels, err := notify.FromConfig(cfg.Monitor.Notifications.Channels)
+			if err != nil {
+				fatal("failed to configure notification channels", logging.Err(err))
+			}
+			cooldown := time.Duration(cfg.Monitor.Notifications.CooldownMinutes) * time.Minute
+			stateNotifier = notify.NewDispatcher(channels, cooldown)
+			slog.Info("link state notifications configured", "channels", len(channels), "cooldown", cooldown)
+		}
 
 		// Initialise et lance le moniteur d'URLs.
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
-		go urlMonitor.Start()
-		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+		monitorOpts := monitor.Options{
+			Interval:           monitorInterval,
+			Jitter:             float64(cfg.Monitor.JitterPercent) / 100,
+			CheckRetention:     time.Duration(cfg.Monitor.CheckRetentionDays) * 24 * time.Hour,
+			Workers:            cfg.Monitor.Workers,
+			PerHostConcurrency: cfg.Monitor.PerHostConcurrency,
+			PerHostRate:        cfg.Monitor.PerHostRequestsPerSecond,
+			FailureThreshold:   cfg.Monitor.FailureThreshold,
+			MaxRedirects:       cfg.Monitor.MaxRedirects,
+		}
+		if stateNotifier != nil {
+			monitorOpts.Notifier = stateNotifier
+		}
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, checkRepo, monitorOpts)
+		monitorDone := make(chan struct{})
+		go func() {
+			defer close(monitorDone)
+			urlMonitor.Start(monitorCtx)
+		}()
+
+		// Configure les vérifications de la sonde de disponibilité.
+		readiness := health.NewChecker(time.Duration(cfg.Health.CheckTimeoutMs) * time.Millisecond)
+		readiness.Register("database", health.DatabaseCheck(sqlDB, cfg.Database.Name))
+		readiness.Register("click_channel", health.ChannelCheck(
+			func() int { return len(clickEventsChannel) },
+			func() int { return cap(clickEventsChannel) },
+			cfg.Health.ClickChannelSaturation,
+		))
+		// Une vérification peut durer bien moins qu'un intervalle : deux intervalles sans signal indiquent une boucle bloquée.
+		readiness.Register("url_monitor", health.HeartbeatCheck(urlMonitor.LastHeartbeat, 2*urlMonitor.Interval()))
 
 		// Configure le routeur Gin et les handlers API.
-		router := gin.Default()
-		api.SetupRoutes(router, linkService, clickEventsChannel)
-		log.Println("Routes API configurées.")
+		router := gin.New()
+		router.Use(gin.Recovery(), api.RequestIDMiddleware(), api.AccessLogMiddleware())
+		api.SetupRoutes(router, linkService, privacyService, linkHealthService, clickEventsChannel, clickSpool, clickBroker, readiness, cfg)
 
 		// Crée le serveur HTTP.
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -74,13 +215,14 @@ puis lance le serveur HTTP.`,
 			Addr:    serverAddr,
 			Handler: router,
 		}
+		// Ferme les flux temps réel à l'arrêt, sans quoi Shutdown attendrait la déconnexion des clients SSE.
+		srv.RegisterOnShutdown(clickBroker.Close)
 
 		// Démarre le serveur HTTP dans une goroutine.
 		go func() {
-			log.Printf("Serveur HTTP démarré sur le port %d", cfg.Server.Port)
-			log.Printf("Accédez à l'API via: %s", cfg.Server.BaseURL)
+			slog.Info("http server started", "port", cfg.Server.Port, "base_url", cfg.Server.BaseURL)
 			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("FATAL: Impossible de démarrer le serveur: %v", err)
+				fatal("failed to start http server", logging.Err(err))
 			}
 		}()
 
@@ -90,16 +232,95 @@ puis lance le serveur HTTP.`,
 
 		// Bloque jusqu'à réception d'un signal d'arrêt.
 		<-quit
-		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
+		slog.Info("shutdown signal received, stopping server")
+
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+
+		// 1. N'accepte plus de requêtes HTTP et attend la fin de celles en cours.
+		httpCtx, cancelHTTP := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := srv.Shutdown(httpCtx); err != nil {
+			slog.Warn("incomplete http server shutdown", logging.Err(err))
+		}
+		cancelHTTP()
+		slog.Info("http server stopped")
+
+		// 2. Arrête le rejeu du spool avant de fermer le channel qu'il alimente.
+		cancelReplay()
+		if clickSpool != nil {
+			<-replayDone
+		}
+
+		// 3. Ferme le channel puis attend que les workers écrivent les clics restants. Si Shutdown a expiré,
+		// les redirections encore en cours déversent leur clic dans le spool au lieu d'écrire dans le channel fermé.
+		persistedBefore := clickWorkers.Metrics().Persisted
+		api.CloseClickEvents()
+		flushTimeout := time.Duration(cfg.Analytics.ShutdownFlushTimeoutSeconds) * time.Second
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), flushTimeout)
+		flushErr := clickWorkers.Wait(flushCtx)
+		if flushErr != nil {
+			slog.Warn("click workers did not finish in time", "timeout", flushTimeout)
+		}
+		cancelFlush()
 
-		// Arrêt propre avec délai pour les workers.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		flushStats := clickWorkers.Metrics()
+		slog.Info("click batch writes",
+			"flushes", flushStats.Flushes, "clicks", flushStats.Clicks,
+			"avg_batch_size", flushStats.AvgBatchSize, "max_batch_size", flushStats.MaxBatchSize,
+			"avg_latency", flushStats.AvgLatency, "max_latency", flushStats.MaxLatency, "failed_flushes", flushStats.FailedFlushes)
+		lost := flushStats.Received - flushStats.Persisted + int64(len(clickEventsChannel))
+		slog.Info("click workers stopped", "persisted_during_shutdown", flushStats.Persisted-persistedBefore, "lost", lost)
+
+		// Ferme le spool une fois les événements rejoués acquittés : seuls les non acquittés y sont conservés.
+		if clickSpool != nil {
+			if err := clickSpool.Close(); err != nil {
+				slog.Error("failed to close click spool", logging.Err(err))
+			}
+			slog.Info("spooled clicks left for next start", "events", clickSpool.Pending())
+		}
 
-		log.Println("Serveur arrêté proprement.")
+		// Vide les sinks une fois les derniers lots exportés ; des workers encore actifs pourraient y écrire.
+		if clickSink != nil && flushErr == nil {
+			if err := clickSink.Close(); err != nil {
+				slog.Error("failed to close click sinks", logging.Err(err))
+			}
+		}
+
+		// 4. Arrête le moniteur d'URLs et le job de rétention, et attend la fin de leur passage en cours.
+		cancelMonitor()
+		stopCtx, cancelStop := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelStop()
+		select {
+		case <-monitorDone:
+		case <-stopCtx.Done():
+			slog.Warn("url monitor did not stop in time", "timeout", shutdownTimeout)
+		}
+		select {
+		case <-retentionDone:
+		case <-stopCtx.Done():
+			slog.Warn("click retention job did not stop in time", "timeout", shutdownTimeout)
+		}
+		// Envoie les dernières notifications une fois le moniteur arrêté.
+		if stateNotifier != nil {
+			stateNotifier.Close(stopCtx)
+		}
+
+		// 5. Ferme la base de données, sauf si des workers y écrivent encore : la fin du processus l'interrompra.
+		if flushErr != nil {
+			slog.Warn("database left open, click workers still writing")
+		} else if err := sqlDB.Close(); err != nil {
+			slog.Error("failed to close database", logging.Err(err))
+		}
+
+		slog.Info("server stopped cleanly")
 	},
 }
 
+// fatal journalise une erreur empêchant le démarrage du serveur puis arrête le programme.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func init() {
 	cmd2.RootCmd.AddCommand(RunServerCmd)
 }
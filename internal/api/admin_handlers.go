@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuthMiddleware n'autorise que les requêtes portant le jeton d'administration
+// dans l'en-tête Authorization: Bearer. Sans jeton configuré, toutes les requêtes sont refusées (503).
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "This route requires server.admin_token to be configured"})
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// EraseClicksHandler gère la suppression des clics d'une personne (DELETE /api/v1/admin/clicks?ip=|visitor_hash=).
+func EraseClicksHandler(privacyService *services.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		audit, err := privacyService.EraseClicks(c.Request.Context(), services.ErasureRequest{
+			IP:          c.Query("ip"),
+			VisitorHash: c.Query("visitor_hash"),
+			Actor:       "api",
+			Reason:      c.Query("reason"),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidErasureRequest) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "failed to erase clicks", logging.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"deleted": audit.DeletedCount,
+			"audit":   audit,
+		})
+	}
+}
+
+// ListErasuresHandler gère la consultation des audits d'effacement (GET /api/v1/admin/erasures?limit=).
+func ListErasuresHandler(privacyService *services.PrivacyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query struct {
+			Limit int `form:"limit"`
+		}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		audits, err := privacyService.ListErasures(c.Request.Context(), query.Limit)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "failed to list erasure audits", logging.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"erasures": audits})
+	}
+}
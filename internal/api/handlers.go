@@ -2,51 +2,124 @@ package api
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/broker"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"gorm.io/gorm"
 )
 
 // ClickEventsChannel est le channel global pour envoyer les événements de clic aux workers asynchrones.
+// Il doit être fermé par CloseClickEvents, jamais directement : des redirections peuvent encore y écrire.
 var ClickEventsChannel chan models.ClickEvent
 
+// clickEventsMu empêche la fermeture de ClickEventsChannel pendant un envoi ; clickEventsClosed indique sa fermeture.
+var (
+	clickEventsMu     sync.RWMutex
+	clickEventsClosed bool
+)
+
+// CloseClickEvents ferme ClickEventsChannel. Les redirections traitées ensuite déversent leur événement
+// dans le spool, ou le perdent si aucun spool n'est configuré.
+func CloseClickEvents() {
+	clickEventsMu.Lock()
+	defer clickEventsMu.Unlock()
+	if clickEventsClosed {
+		return
+	}
+	clickEventsClosed = true
+	close(ClickEventsChannel)
+}
+
+// sendClickEvent place un événement dans ClickEventsChannel sans bloquer.
+// Elle renvoie false si le channel est plein ou fermé.
+func sendClickEvent(event models.ClickEvent) bool {
+	clickEventsMu.RLock()
+	defer clickEventsMu.RUnlock()
+	if clickEventsClosed {
+		return false
+	}
+	select {
+	case ClickEventsChannel <- event:
+		return true
+	default:
+		return false
+	}
+}
+
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires.
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickEventsChannel chan models.ClickEvent) {
+// clickSpool peut être nil : les clics en excès sont alors perdus.
+// Les routes d'administration et les flux de clics ne sont exposés que si un jeton d'administration est configuré.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, privacyService *services.PrivacyService, linkHealthService *services.LinkHealthService, clickEventsChannel chan models.ClickEvent, clickSpool *spool.Spool, clickBroker *broker.Broker, readiness *health.Checker, cfg *config.Config) {
 	if ClickEventsChannel == nil {
 		ClickEventsChannel = clickEventsChannel
 	}
 
-	// Route de santé.
-	router.GET("/health", HealthCheckHandler)
+	// Sondes de santé ; /health est conservée pour compatibilité et équivaut à /livez,
+	// pour ne pas faire redémarrer un service simplement chargé par les sondes existantes.
+	router.GET("/livez", LivenessHandler)
+	router.GET("/readyz", ReadinessHandler(readiness))
+	router.GET("/health", LivenessHandler)
 
-	// Routes de l'API v1.
+	// Métriques Prometheus.
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Routes de l'API v1. Les modifications d'un lien existant exigent le jeton d'administration.
+	adminAuth := AdminAuthMiddleware(cfg.Server.AdminToken)
 	api := router.Group("/api/v1")
 	{
-		api.POST("/links", CreateShortLinkHandler(linkService))
+		api.POST("/links", CreateShortLinkHandler(linkService, cfg.Server.BaseURL))
+		api.GET("/links", ListLinksHandler(linkService))
+		api.GET("/links/:shortCode", GetLinkHandler(linkService))
+		api.PATCH("/links/:shortCode", adminAuth, UpdateLinkHandler(linkService))
+		api.DELETE("/links/:shortCode", adminAuth, DeleteLinkHandler(linkService))
+		api.POST("/links/:shortCode/restore", adminAuth, RestoreLinkHandler(linkService))
+		api.POST("/links/:shortCode/activate", adminAuth, SetLinkActiveHandler(linkService, true))
+		api.POST("/links/:shortCode/deactivate", adminAuth, SetLinkActiveHandler(linkService, false))
 		api.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		api.GET("/links/:shortCode/stats/timeseries", GetLinkTimeSeriesHandler(linkService))
+		api.GET("/links/:shortCode/health", GetLinkHealthHandler(linkHealthService))
 	}
 
-	// Route de redirection.
-	router.GET("/:shortCode", RedirectHandler(linkService))
-}
+	// Routes d'administration et flux temps réel des clics, qui exposent l'activité des visiteurs.
+	// Sans jeton configuré, elles répondent 503 plutôt que d'être absentes.
+	api.GET("/links/:shortCode/events", adminAuth, LinkClickStreamHandler(linkService, clickBroker))
+	api.GET("/events", adminAuth, ClickStreamHandler(clickBroker))
 
-// HealthCheckHandler gère la route /health pour vérifier l'état du service.
-func HealthCheckHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	admin := api.Group("/admin", adminAuth)
+	{
+		admin.DELETE("/clicks", EraseClicksHandler(privacyService))
+		admin.GET("/erasures", ListErasuresHandler(privacyService))
+	}
+
+	// Route de redirection.
+	router.GET("/:shortCode", RedirectHandler(linkService, clickSpool, cfg.Redirect))
 }
 
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"`
+	LongURL   string     `json:"long_url" binding:"required,url"`
+	Alias     string     `json:"alias"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxClicks int        `json:"max_clicks" binding:"min=0"`
+	// FallbackURL et FailoverPolicy définissent le comportement de la redirection si l'URL longue est déclarée inaccessible.
+	FallbackURL    string `json:"fallback_url" binding:"omitempty,url"`
+	FailoverPolicy string `json:"failover_policy"`
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
-func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+func CreateShortLinkHandler(linkService *services.LinkService, baseURL string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req CreateLinkRequest
 		// Lie et valide le JSON de la requête.
@@ -56,55 +129,285 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 		}
 
 		// Crée le nouveau lien court.
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(c.Request.Context(), req.LongURL, services.CreateLinkOptions{
+			Alias:     req.Alias,
+			ExpiresAt: req.ExpiresAt,
+			MaxClicks: req.MaxClicks,
+
+			FallbackURL:    req.FallbackURL,
+			FailoverPolicy: req.FailoverPolicy,
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
+			switch {
+			case errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrReservedAlias),
+				errors.Is(err, services.ErrInvalidExpiration), errors.Is(err, services.ErrInvalidFailover):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, services.ErrAliasTaken):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
+			}
 			return
 		}
+		metrics.LinksCreatedTotal.Inc()
 
 		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": "http://localhost:8080/" + link.ShortCode,
+			"short_code":      link.ShortCode,
+			"long_url":        link.LongURL,
+			"full_short_url":  baseURL + "/" + link.ShortCode,
+			"expires_at":      link.ExpiresAt,
+			"max_clicks":      link.MaxClicks,
+			"fallback_url":    link.FallbackURL,
+			"failover_policy": link.FailoverPolicy,
+		})
+	}
+}
+
+// UpdateLinkRequest représente le corps de la requête JSON pour la modification partielle d'un lien.
+type UpdateLinkRequest struct {
+	LongURL        *string `json:"long_url" binding:"omitempty,url"`
+	IsActive       *bool   `json:"is_active"`
+	FallbackURL    *string `json:"fallback_url"` // Une chaîne vide retire l'URL de secours.
+	FailoverPolicy *string `json:"failover_policy"`
+}
+
+// ListLinksQuery représente les paramètres de requête de la liste paginée des liens.
+type ListLinksQuery struct {
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Active      *bool      `form:"active"`
+	Domain      string     `form:"domain"`
+	Search      string     `form:"q"`
+	Sort        string     `form:"sort" binding:"omitempty,oneof=created clicks"`
+	Order       string     `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor      string     `form:"cursor"`
+	Limit       int        `form:"limit" binding:"min=0"`
+}
+
+// ListLinksHandler gère la récupération paginée, filtrée et triée des liens.
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query ListLinksQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		page, err := linkService.ListLinks(c.Request.Context(), services.ListLinksInput{
+			CreatedFrom: query.CreatedFrom,
+			CreatedTo:   query.CreatedTo,
+			IsActive:    query.Active,
+			Domain:      query.Domain,
+			Search:      query.Search,
+			SortBy:      query.Sort,
+			Order:       query.Order,
+			Cursor:      query.Cursor,
+			Limit:       query.Limit,
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "failed to list links", logging.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// GetLinkHandler gère la récupération d'un lien par son code court.
+func GetLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.GetLinkByShortCode(c.Request.Context(), c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// UpdateLinkHandler gère la modification de la destination, de l'état ou du comportement de secours d'un lien.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, err := linkService.UpdateLink(c.Request.Context(), c.Param("shortCode"), services.UpdateLinkInput{
+			LongURL:        req.LongURL,
+			IsActive:       req.IsActive,
+			FallbackURL:    req.FallbackURL,
+			FailoverPolicy: req.FailoverPolicy,
 		})
+		if err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// DeleteLinkHandler gère la suppression logique d'un lien.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := linkService.DeleteLink(c.Request.Context(), c.Param("shortCode")); err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreLinkHandler gère la restauration d'un lien supprimé.
+func RestoreLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.RestoreLink(c.Request.Context(), c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// SetLinkActiveHandler gère l'activation ou la désactivation d'un lien.
+func SetLinkActiveHandler(linkService *services.LinkService, active bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.SetLinkActive(c.Request.Context(), c.Param("shortCode"), active)
+		if err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, link)
+	}
+}
+
+// respondLinkError traduit une erreur d'accès à un lien en réponse HTTP.
+func respondLinkError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
+		return
 	}
+	if errors.Is(err, services.ErrInvalidFailover) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "failed to handle link", logging.KeyShortCode, c.Param("shortCode"), logging.Err(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
-func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Si le channel est plein, l'événement est écrit dans clickSpool lorsqu'il est fourni.
+func RedirectHandler(linkService *services.LinkService, clickSpool *spool.Spool, redirectCfg config.RedirectConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		start := time.Now()
+		defer func() { metrics.ObserveRedirect(c.Writer.Status(), time.Since(start)) }()
 		shortCode := c.Param("shortCode")
 
 		// Récupère l'URL longue associée au shortCode.
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.GetLinkByShortCode(c.Request.Context(), shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
 				return
 			}
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			slog.ErrorContext(c.Request.Context(), "failed to get link", logging.KeyShortCode, shortCode, logging.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		// Refuse la redirection d'un lien désactivé, expiré ou dont le budget de clics est épuisé.
+		if err := linkService.CheckLinkAvailability(c.Request.Context(), link); err != nil {
+			if errors.Is(err, services.ErrLinkDisabled) {
+				respondDisabled(c, redirectCfg)
+				return
+			}
+			if errors.Is(err, services.ErrLinkExpired) || errors.Is(err, services.ErrLinkExhausted) {
+				respondGone(c, err, redirectCfg.ExpiredFallbackURL)
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "failed to check link availability", logging.KeyLinkID, link.ID, logging.KeyShortCode, shortCode, logging.Err(err))
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
+		// Applique la politique de secours du lien si le moniteur a déclaré l'URL longue inaccessible.
+		if link.HealthStatus == models.LinkHealthDown {
+			metrics.DownDestinationVisitsTotal.WithLabelValues(link.FailoverPolicy).Inc()
+		}
+		target, err := linkService.RedirectTarget(link)
+		if errors.Is(err, services.ErrDestinationUnavailable) {
+			respondUnavailable(c)
+			return
+		}
+
 		// Crée un événement de clic.
 		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
 			UserAgent: c.GetHeader("User-Agent"),
 			IPAddress: c.ClientIP(),
+			Referrer:  c.GetHeader("Referer"),
 		}
 
-		// Envoie l'événement dans le channel sans bloquer.
-		select {
-		case ClickEventsChannel <- clickEvent:
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		// Envoie l'événement dans le channel sans bloquer, ou le déverse sur disque s'il est plein ou fermé.
+		if !sendClickEvent(clickEvent) {
+			if clickSpool == nil {
+				metrics.ClickEventsOverflowTotal.WithLabelValues(metrics.OverflowDropped).Inc()
+				slog.WarnContext(c.Request.Context(), "click channel unavailable, dropping click event", logging.KeyLinkID, link.ID, logging.KeyShortCode, shortCode)
+			} else if err := clickSpool.Append(clickEvent); err != nil {
+				metrics.ClickEventsOverflowTotal.WithLabelValues(metrics.OverflowDropped).Inc()
+				slog.ErrorContext(c.Request.Context(), "click channel unavailable and spooling failed, dropping click event", logging.KeyLinkID, link.ID, logging.KeyShortCode, shortCode, logging.Err(err))
+			} else {
+				metrics.ClickEventsOverflowTotal.WithLabelValues(metrics.OverflowSpooled).Inc()
+			}
 		}
 
 		// Effectue la redirection.
-		c.Redirect(http.StatusFound, link.LongURL)
+		c.Redirect(http.StatusFound, target)
+	}
+}
+
+// respondGone répond à la visite d'un lien qui n'est plus disponible,
+// en redirigeant vers l'URL de repli si elle est configurée.
+func respondGone(c *gin.Context, reason error, fallbackURL string) {
+	if fallbackURL != "" {
+		c.Redirect(http.StatusFound, fallbackURL)
+		return
+	}
+	c.JSON(http.StatusGone, gin.H{"error": reason.Error()})
+}
+
+// unavailablePage est la page affichée aux visiteurs d'un lien dont la destination est déclarée inaccessible.
+const unavailablePage = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>Destination unavailable</title></head>
+<body>
+<h1>Destination unavailable</h1>
+<p>The destination of this link is currently unreachable. Please try again later.</p>
+</body>
+</html>
+`
+
+// respondUnavailable répond à la visite d'un lien dont la destination est déclarée inaccessible.
+// La page n'est pas mise en cache, pour que la redirection reprenne dès le rétablissement de la destination.
+func respondUnavailable(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusServiceUnavailable, "text/html; charset=utf-8", []byte(unavailablePage))
+}
+
+// respondDisabled répond à la visite d'un lien désactivé selon la configuration.
+func respondDisabled(c *gin.Context, redirectCfg config.RedirectConfig) {
+	if redirectCfg.DisabledFallbackURL != "" {
+		c.Redirect(http.StatusFound, redirectCfg.DisabledFallbackURL)
+		return
 	}
+	c.JSON(redirectCfg.DisabledStatusCode, gin.H{"error": redirectCfg.DisabledMessage})
 }
 
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
@@ -112,7 +415,7 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 		// Récupère le lien et ses statistiques.
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		link, stats, err := linkService.GetLinkStats(c.Request.Context(), shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
@@ -123,9 +426,89 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"short_code":                  link.ShortCode,
+			"long_url":                    link.LongURL,
+			"total_clicks":                stats.TotalClicks,
+			"unique_visitors":             stats.UniqueVisitors,
+			"unique_visitors_approximate": stats.UniqueVisitorsApproximate,
+			"unique_visitors_by_day":      stats.UniqueVisitorsByDay,
+			"breakdowns":                  stats.Breakdowns,
+		})
+	}
+}
+
+// GetLinkTimeSeriesHandler gère la récupération des clics d'un lien agrégés par heure, jour ou semaine.
+// Paramètres : from et to (RFC3339 ou AAAA-MM-JJ, 7 derniers jours par défaut), interval et tz.
+// Les clics purgés ne sont connus que par jour (UTC) : une journée agrégée partiellement couverte par
+// la plage compte au prorata de la durée couverte (voir TimeSeries.RolledUpClicks).
+func GetLinkTimeSeriesHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+			return
+		}
+
+		to := time.Now()
+		if raw := c.Query("to"); raw != "" {
+			if to, err = services.ParseTimeBound(raw, loc); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date"})
+				return
+			}
+		}
+		from := to.AddDate(0, 0, -7)
+		if raw := c.Query("from"); raw != "" {
+			if from, err = services.ParseTimeBound(raw, loc); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date"})
+				return
+			}
+		}
+
+		link, series, err := linkService.GetLinkTimeSeries(c.Request.Context(), c.Param("shortCode"), from, to,
+			c.DefaultQuery("interval", services.IntervalDay), loc)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			respondLinkError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"long_url":   link.LongURL,
+			"timeseries": series,
+		})
+	}
+}
+
+// GetLinkHealthHandler gère la consultation de l'état de santé d'un lien et de son historique
+// (GET /api/v1/links/:shortCode/health?days=).
+func GetLinkHealthHandler(linkHealthService *services.LinkHealthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query struct {
+			Days int `form:"days"`
+		}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		link, report, err := linkHealthService.GetLinkHealth(c.Request.Context(), c.Param("shortCode"), query.Days)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			respondLinkError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"long_url":   link.LongURL,
+			"health":     report,
 		})
 	}
 }
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessHandler gère la sonde de vivacité (GET /livez) : le processus répond, sans vérifier ses dépendances.
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// ReadinessHandler gère la sonde de disponibilité (GET /readyz) : elle vérifie chaque dépendance
+// et renvoie le rapport détaillé, avec 503 Service Unavailable si l'une d'elles n'est pas prête.
+func ReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader est l'en-tête portant l'identifiant de requête, reçu du client ou généré.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern borne les identifiants de requête acceptés du client.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware reprend l'identifiant X-Request-ID du client ou en génère un, le renvoie dans la réponse
+// et le place dans le contexte de la requête pour qu'il figure dans tous les journaux qui en découlent.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware journalise chaque requête terminée avec son statut et sa durée.
+// Les erreurs serveur sont journalisées en ERROR, les erreurs client en WARN et les autres en INFO.
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		slog.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"path", path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency", time.Since(start),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
+
+// newRequestID génère un identifiant de requête aléatoire de 16 octets en hexadécimal.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/broker"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval est le délai entre deux commentaires SSE envoyés pour garder la connexion ouverte.
+const streamHeartbeatInterval = 15 * time.Second
+
+// StreamClick est la représentation d'un clic diffusée en temps réel. Elle ne contient aucune donnée
+// identifiant le visiteur (adresse IP, User-Agent, empreinte) ni l'URL complète du référent.
+type StreamClick struct {
+	LinkID       uint      `json:"link_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Country      string    `json:"country"`
+	DeviceType   string    `json:"device_type"`
+	ReferrerHost string    `json:"referrer_host"`
+}
+
+// newStreamClick convertit un clic en représentation diffusée.
+func newStreamClick(click models.Click) StreamClick {
+	return StreamClick{
+		LinkID:       click.LinkID,
+		Timestamp:    click.Timestamp,
+		Country:      click.Country,
+		DeviceType:   click.DeviceType,
+		ReferrerHost: click.ReferrerHost,
+	}
+}
+
+// LinkClickStreamHandler diffuse en Server-Sent Events les clics d'un lien (GET /api/v1/links/:shortCode/events).
+func LinkClickStreamHandler(linkService *services.LinkService, clickBroker *broker.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.GetLinkByShortCode(c.Request.Context(), c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, err)
+			return
+		}
+		streamClicks(c, clickBroker, link.ID)
+	}
+}
+
+// ClickStreamHandler diffuse en Server-Sent Events les clics de tous les liens (GET /api/v1/events).
+func ClickStreamHandler(clickBroker *broker.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		streamClicks(c, clickBroker, 0)
+	}
+}
+
+// streamClicks abonne la requête au broker et lui envoie un événement "click" par clic, jusqu'à la déconnexion
+// du client ou la fermeture du broker. Un client trop lent reçoit un événement "dropped" avant la fermeture du flux.
+func streamClicks(c *gin.Context, clickBroker *broker.Broker, linkID uint) {
+	sub := clickBroker.Subscribe(linkID)
+	defer clickBroker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case click, ok := <-sub.C:
+			if !ok {
+				if clickBroker.Dropped(sub) {
+					c.SSEvent("dropped", gin.H{"error": "Subscriber too slow, stream closed"})
+				}
+				return false
+			}
+			c.SSEvent("click", newStreamClick(click))
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
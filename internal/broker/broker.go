@@ -0,0 +1,115 @@
+// Package broker diffuse les clics traités par les workers aux abonnés du flux temps réel.
+//
+// La publication ne bloque jamais : un abonné dont le buffer est plein est désabonné
+// plutôt que de ralentir les workers et, en amont, les redirections.
+package broker
+
+import (
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// defaultBufferSize est la taille par défaut du buffer de chaque abonné.
+const defaultBufferSize = 100
+
+// Broker diffuse chaque clic publié aux abonnés concernés.
+type Broker struct {
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription est l'abonnement d'un client au flux de clics.
+// Son channel C est fermé au désabonnement, à la fermeture du broker ou si le client est trop lent.
+type Subscription struct {
+	C       <-chan models.Click
+	ch      chan models.Click
+	linkID  uint // 0 pour recevoir les clics de tous les liens.
+	dropped bool
+}
+
+// New crée un broker dont chaque abonné dispose d'un buffer de bufferSize clics.
+func New(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	return &Broker{
+		bufferSize: bufferSize,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe abonne un client aux clics du lien linkID, ou de tous les liens si linkID vaut 0.
+func (b *Broker) Subscribe(linkID uint) *Subscription {
+	ch := make(chan models.Click, b.bufferSize)
+	sub := &Subscription{C: ch, ch: ch, linkID: linkID}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe retire un abonné ; sans effet s'il a déjà été retiré.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub)
+}
+
+// Dropped indique si l'abonné a été retiré parce qu'il ne consommait pas assez vite.
+func (b *Broker) Dropped(sub *Subscription) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return sub.dropped
+}
+
+// Publish transmet un clic aux abonnés concernés sans jamais bloquer.
+func (b *Broker) Publish(click models.Click) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if sub.linkID != 0 && sub.linkID != click.LinkID {
+			continue
+		}
+		select {
+		case sub.ch <- click:
+		default:
+			sub.dropped = true
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribers renvoie le nombre d'abonnés actifs.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close désabonne tous les clients ; les publications suivantes sont ignorées.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		b.removeLocked(sub)
+	}
+}
+
+// removeLocked retire un abonné et ferme son channel. L'appelant doit détenir b.mu.
+func (b *Broker) removeLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
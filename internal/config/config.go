@@ -2,7 +2,7 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/spf13/viper"
 )
@@ -13,11 +13,19 @@ type Config struct {
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Analytics AnalyticsConfig `mapstructure:"analytics"`
 	Monitor   MonitorConfig   `mapstructure:"monitor"`
+	Redirect  RedirectConfig  `mapstructure:"redirect"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
+	Health    HealthConfig    `mapstructure:"health"`
 }
 
 type ServerConfig struct {
 	Port    int    `mapstructure:"port"`
 	BaseURL string `mapstructure:"base_url"`
+	// AdminToken protège les routes /api/v1/admin, les modifications de liens et les flux de clics
+	// (en-tête Authorization: Bearer) ; vide pour les désactiver.
+	AdminToken string `mapstructure:"admin_token"`
+	// ShutdownTimeoutSeconds borne l'attente des requêtes HTTP en cours et du moniteur à l'arrêt.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 }
 
 type DatabaseConfig struct {
@@ -27,10 +35,133 @@ type DatabaseConfig struct {
 type AnalyticsConfig struct {
 	BufferSize  int `mapstructure:"buffer_size"`
 	WorkerCount int `mapstructure:"worker_count"`
+	// BatchSize et FlushIntervalMs déclenchent l'écriture groupée des clics accumulés par chaque worker.
+	BatchSize       int `mapstructure:"batch_size"`
+	FlushIntervalMs int `mapstructure:"flush_interval_ms"`
+	// UniqueVisitorsHLLThreshold active les sketches HyperLogLog quotidiens enregistrés par les workers :
+	// au-delà de ce nombre de clics, les visiteurs uniques d'un lien en sont estimés (0 : pas de sketch, toujours exact).
+	UniqueVisitorsHLLThreshold int `mapstructure:"unique_visitors_hll_threshold"`
+	// GeoIPDatabase est le chemin d'une base MaxMind (.mmdb) locale ; vide pour ne pas géolocaliser.
+	GeoIPDatabase string `mapstructure:"geoip_database"`
+	// IPAnonymization est le traitement des adresses IP avant leur enregistrement : none, truncate ou hash.
+	IPAnonymization string `mapstructure:"ip_anonymization"`
+	// IPHashKey est la clé secrète du mode hash.
+	IPHashKey string `mapstructure:"ip_hash_key"`
+	// Spool configure la file d'attente sur disque des clics qui ne tiennent plus dans le buffer.
+	Spool SpoolConfig `mapstructure:"spool"`
+	// Retention configure l'agrégation quotidienne et la purge des clics bruts anciens.
+	Retention RetentionConfig `mapstructure:"retention"`
+	// StreamBufferSize est le nombre de clics en attente au-delà duquel un abonné du flux temps réel est déconnecté.
+	StreamBufferSize int `mapstructure:"stream_buffer_size"`
+	// Sinks liste les destinations externes vers lesquelles les clics sont exportés en plus de la base.
+	Sinks []SinkConfig `mapstructure:"sinks"`
+	// ShutdownFlushTimeoutSeconds borne l'attente de l'écriture des clics restants à l'arrêt.
+	ShutdownFlushTimeoutSeconds int `mapstructure:"shutdown_flush_timeout_seconds"`
+}
+
+type SpoolConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	Dir              string `mapstructure:"dir"`
+	MaxSegmentMB     int    `mapstructure:"max_segment_mb"`
+	ReplayIntervalMs int    `mapstructure:"replay_interval_ms"`
+	// SyncIntervalMs est le délai maximal avant qu'un clic déversé soit forcé sur disque (0 : à chaque clic).
+	SyncIntervalMs int `mapstructure:"sync_interval_ms"`
+}
+
+type RetentionConfig struct {
+	// RawClickDays est le nombre de jours (UTC) pendant lesquels les clics bruts sont conservés ;
+	// 0 pour les conserver indéfiniment.
+	RawClickDays          int `mapstructure:"raw_click_days"`
+	RollupIntervalMinutes int `mapstructure:"rollup_interval_minutes"`
+}
+
+// SinkConfig décrit un sink d'export des clics. Seuls les champs propres à son type sont utilisés.
+type SinkConfig struct {
+	Type string `mapstructure:"type"` // file, webhook ou stdout
+	// Sink fichier JSON Lines.
+	Path       string `mapstructure:"path"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`
+	MaxBackups int    `mapstructure:"max_backups"`
+	// Sink webhook.
+	URL             string            `mapstructure:"url"`
+	BatchSize       int               `mapstructure:"batch_size"`
+	FlushIntervalMs int               `mapstructure:"flush_interval_ms"`
+	TimeoutMs       int               `mapstructure:"timeout_ms"`
+	MaxRetries      int               `mapstructure:"max_retries"`
+	QueueSize       int               `mapstructure:"queue_size"`
+	Headers         map[string]string `mapstructure:"headers"`
 }
 
 type MonitorConfig struct {
 	IntervalMinutes int `mapstructure:"interval_minutes"`
+	// CheckRetentionDays est le nombre de jours d'historique des vérifications conservés ; 0 pour tout conserver.
+	CheckRetentionDays int `mapstructure:"check_retention_days"`
+	// JitterPercent est la variation aléatoire de l'intervalle entre deux passes, en pourcentage.
+	JitterPercent int `mapstructure:"jitter_percent"`
+	// Workers est le nombre de vérifications menées en parallèle.
+	Workers int `mapstructure:"workers"`
+	// PerHostConcurrency et PerHostRequestsPerSecond limitent les vérifications d'un même hôte
+	// (PerHostRequestsPerSecond à 0 : pas de limite de cadence).
+	PerHostConcurrency       int     `mapstructure:"per_host_concurrency"`
+	PerHostRequestsPerSecond float64 `mapstructure:"per_host_requests_per_second"`
+	// FailureThreshold est le nombre de vérifications en échec consécutives à partir duquel un lien est déclaré inaccessible.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// MaxRedirects est le nombre maximal de redirections suivies lors d'une vérification.
+	MaxRedirects int `mapstructure:"max_redirects"`
+	// Notifications configure l'envoi des changements d'état des liens.
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+}
+
+type NotificationsConfig struct {
+	// CooldownMinutes est le délai minimal entre deux notifications d'un même lien ; les changements d'état
+	// survenus entre-temps sont résumés à son expiration, ce qui évite d'être inondé par un lien instable.
+	CooldownMinutes int `mapstructure:"cooldown_minutes"`
+	// Channels liste les canaux de notification.
+	Channels []NotifierConfig `mapstructure:"channels"`
+}
+
+// NotifierConfig décrit un canal de notification. Seuls les champs propres à son type sont utilisés.
+type NotifierConfig struct {
+	Type string `mapstructure:"type"` // webhook, slack ou email
+	// TimeoutMs borne chaque tentative d'envoi : requête HTTP ou session SMTP.
+	TimeoutMs int `mapstructure:"timeout_ms"`
+	// Webhook générique et webhook entrant Slack.
+	URL        string            `mapstructure:"url"`
+	Secret     string            `mapstructure:"secret"` // Clé de la signature HMAC-SHA256 du webhook générique.
+	Headers    map[string]string `mapstructure:"headers"`
+	MaxRetries *int              `mapstructure:"max_retries"` // Nouvelles tentatives après un échec : 3 si absent, 0 pour aucune.
+	// Email via SMTP.
+	SMTPHost string   `mapstructure:"smtp_host"`
+	SMTPPort int      `mapstructure:"smtp_port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+type RedirectConfig struct {
+	// ExpiredFallbackURL reçoit les visiteurs d'un lien expiré ou épuisé ; vide pour répondre 410 Gone.
+	ExpiredFallbackURL string `mapstructure:"expired_fallback_url"`
+	// DisabledStatusCode (4xx ou 5xx) et DisabledMessage composent la réponse servie pour un lien désactivé.
+	DisabledStatusCode int    `mapstructure:"disabled_status_code"`
+	DisabledMessage    string `mapstructure:"disabled_message"`
+	// DisabledFallbackURL reçoit les visiteurs d'un lien désactivé ; prioritaire sur la réponse ci-dessus.
+	DisabledFallbackURL string `mapstructure:"disabled_fallback_url"`
+}
+
+type LoggingConfig struct {
+	// Level est le niveau minimal journalisé : debug, info, warn ou error.
+	Level string `mapstructure:"level"`
+	// Format est le format des journaux : text ou json.
+	Format string `mapstructure:"format"`
+}
+
+type HealthConfig struct {
+	// ClickChannelSaturation est le taux d'occupation du channel des clics (0 à 1) à partir duquel
+	// le service n'est plus considéré comme prêt.
+	ClickChannelSaturation float64 `mapstructure:"click_channel_saturation"`
+	// CheckTimeoutMs borne la durée de chaque vérification de /readyz.
+	CheckTimeoutMs int `mapstructure:"check_timeout_ms"`
 }
 
 // LoadConfig charge la configuration depuis le fichier config.yaml ou utilise les valeurs par défaut.
@@ -43,15 +174,48 @@ func LoadConfig() (*Config, error) {
 	// Définit les valeurs par défaut.
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.admin_token", "")
+	viper.SetDefault("server.shutdown_timeout_seconds", 10)
 	viper.SetDefault("database.name", "url_shortener_grp5.db")
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.batch_size", 100)
+	viper.SetDefault("analytics.flush_interval_ms", 1000)
+	viper.SetDefault("analytics.unique_visitors_hll_threshold", 0)
+	viper.SetDefault("analytics.geoip_database", "")
+	viper.SetDefault("analytics.ip_anonymization", "truncate")
+	viper.SetDefault("analytics.ip_hash_key", "")
+	viper.SetDefault("analytics.shutdown_flush_timeout_seconds", 10)
+	viper.SetDefault("analytics.stream_buffer_size", 100)
+	viper.SetDefault("analytics.retention.raw_click_days", 0)
+	viper.SetDefault("analytics.retention.rollup_interval_minutes", 60)
+	viper.SetDefault("analytics.spool.enabled", false)
+	viper.SetDefault("analytics.spool.dir", "spool")
+	viper.SetDefault("analytics.spool.max_segment_mb", 16)
+	viper.SetDefault("analytics.spool.replay_interval_ms", 1000)
+	viper.SetDefault("analytics.spool.sync_interval_ms", 1000)
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("monitor.check_retention_days", 30)
+	viper.SetDefault("monitor.jitter_percent", 10)
+	viper.SetDefault("monitor.workers", 10)
+	viper.SetDefault("monitor.per_host_concurrency", 2)
+	viper.SetDefault("monitor.per_host_requests_per_second", 1)
+	viper.SetDefault("monitor.failure_threshold", 3)
+	viper.SetDefault("monitor.max_redirects", 10)
+	viper.SetDefault("monitor.notifications.cooldown_minutes", 30)
+	viper.SetDefault("redirect.expired_fallback_url", "")
+	viper.SetDefault("redirect.disabled_status_code", 403)
+	viper.SetDefault("redirect.disabled_message", "This link has been disabled")
+	viper.SetDefault("redirect.disabled_fallback_url", "")
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("health.click_channel_saturation", 0.9)
+	viper.SetDefault("health.check_timeout_ms", 2000)
 
 	// Lit le fichier de configuration.
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("Fichier de configuration non trouvé, utilisation des valeurs par défaut")
+			slog.Info("config file not found, using defaults")
 		} else {
 			return nil, fmt.Errorf("erreur lors de la lecture du fichier de configuration: %w", err)
 		}
@@ -63,8 +227,13 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("erreur lors du demap de la configuration: %w", err)
 	}
 
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	// Un code hors 4xx/5xx ne peut pas signaler un lien désactivé (et 0 ferait échouer chaque visite).
+	if code := cfg.Redirect.DisabledStatusCode; code < 400 || code > 599 {
+		return nil, fmt.Errorf("redirect.disabled_status_code invalide (%d): un code 4xx ou 5xx est attendu", code)
+	}
+
+	slog.Info("configuration loaded", "port", cfg.Server.Port, "database", cfg.Database.Name,
+		"buffer_size", cfg.Analytics.BufferSize, "monitor_interval_minutes", cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil
 }
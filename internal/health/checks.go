@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DatabaseCheck vérifie que la base répond. Pour une base SQLite sur disque (path non vide),
+// elle vérifie aussi que le fichier existe encore : une connexion ouverte survit à sa suppression.
+func DatabaseCheck(db *sql.DB, path string) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		if path != "" && !isMemoryDatabase(path) {
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("database file unavailable: %w", err)
+			}
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return nil, fmt.Errorf("database ping failed: %w", err)
+		}
+		var one int
+		if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+			return nil, fmt.Errorf("database query failed: %w", err)
+		}
+		stats := db.Stats()
+		return map[string]any{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+		}, nil
+	}
+}
+
+// isMemoryDatabase indique si le nom de base SQLite désigne une base en mémoire ou une URI.
+func isMemoryDatabase(path string) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file:")
+}
+
+// ChannelCheck vérifie que l'occupation d'un channel reste sous le seuil saturation (entre 0 et 1).
+// depth et capacity renvoient l'occupation et la capacité courantes du channel.
+func ChannelCheck(depth, capacity func() int, saturation float64) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		used, size := depth(), capacity()
+		details := map[string]any{
+			"depth":    used,
+			"capacity": size,
+		}
+		if size == 0 {
+			return details, nil
+		}
+		ratio := float64(used) / float64(size)
+		details["saturation"] = ratio
+		if ratio >= saturation {
+			return details, fmt.Errorf("channel saturated (%d/%d)", used, size)
+		}
+		return details, nil
+	}
+}
+
+// HeartbeatCheck vérifie qu'une boucle de fond a signalé son activité depuis moins de maxAge.
+// lastBeat renvoie l'heure du dernier signal, ou l'heure zéro si la boucle n'a pas démarré ou s'est arrêtée.
+func HeartbeatCheck(lastBeat func() time.Time, maxAge time.Duration) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		last := lastBeat()
+		if last.IsZero() {
+			return nil, errors.New("loop not running")
+		}
+		age := time.Since(last)
+		details := map[string]any{
+			"last_heartbeat": last.UTC(),
+			"age":            age.Round(time.Millisecond).String(),
+		}
+		if age > maxAge {
+			return details, fmt.Errorf("no heartbeat for %v", age.Round(time.Second))
+		}
+		return details, nil
+	}
+}
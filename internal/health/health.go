@@ -0,0 +1,127 @@
+// Package health vérifie l'état des dépendances du service pour la sonde de disponibilité (/readyz).
+//
+// Chaque composant est vérifié par une Check indépendante, exécutée en parallèle avec un délai borné.
+// Le service n'est prêt que si tous les composants le sont.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// États d'un composant et du rapport global.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Check vérifie un composant. Elle renvoie des détails facultatifs et une erreur si le composant n'est pas prêt.
+type Check func(ctx context.Context) (map[string]any, error)
+
+// ComponentReport est le résultat de la vérification d'un composant.
+type ComponentReport struct {
+	Status   string         `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+	Duration string         `json:"duration"`
+}
+
+// Report est le résultat de la vérification de tous les composants.
+type Report struct {
+	Status     string                     `json:"status"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// Ready indique si tous les composants sont prêts.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker regroupe les vérifications des composants du service.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+// NewChecker crée un Checker dont chaque vérification est bornée par timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register ajoute la vérification du composant name. Elle doit être appelée avant le premier Run.
+func (c *Checker) Register(name string, check Check) {
+	if _, exists := c.checks[name]; !exists {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Run exécute toutes les vérifications en parallèle et renvoie le rapport.
+// Une vérification qui dépasse le délai est considérée en échec.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusOK,
+		CheckedAt:  time.Now().UTC(),
+		Components: make(map[string]ComponentReport, len(c.names)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			component := c.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[name] = component
+			if component.Status != StatusOK {
+				report.Status = StatusDegraded
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+	return report
+}
+
+// run exécute une vérification avec le délai du Checker.
+func (c *Checker) run(ctx context.Context, check Check) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type result struct {
+		details map[string]any
+		err     error
+	}
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		details, err := check(ctx)
+		done <- result{details, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("check timed out after %v", c.timeout)
+	}
+
+	component := ComponentReport{
+		Status:   StatusOK,
+		Details:  res.details,
+		Duration: time.Since(start).String(),
+	}
+	if res.err != nil {
+		component.Status = StatusDegraded
+		component.Error = res.err.Error()
+	}
+	return component
+}
@@ -0,0 +1,97 @@
+// Package hll implémente un sketch HyperLogLog pour estimer un nombre d'éléments distincts
+// avec une mémoire constante.
+package hll
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// ErrPrecisionMismatch est renvoyée lors de la fusion de sketches de précisions différentes.
+var ErrPrecisionMismatch = errors.New("hll: precision mismatch")
+
+// Sketch est un estimateur HyperLogLog de précision fixe.
+type Sketch struct {
+	precision uint8
+	registers []uint8
+}
+
+// New crée un sketch de 2^precision registres ; l'erreur type est d'environ 1.04/sqrt(2^precision).
+// La précision est bornée à l'intervalle [4, 16].
+func New(precision uint8) *Sketch {
+	precision = min(max(precision, 4), 16)
+	return &Sketch{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add ajoute au sketch un élément représenté par une empreinte 64 bits uniformément distribuée.
+func (s *Sketch) Add(hash uint64) {
+	index := hash >> (64 - s.precision)
+	rest := hash<<s.precision | 1<<(s.precision-1) // Le bit sentinelle borne le rang.
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+	if rank > s.registers[index] {
+		s.registers[index] = rank
+	}
+}
+
+// FromRegisters reconstruit un sketch à partir de registres enregistrés par Registers.
+func FromRegisters(registers []byte) (*Sketch, error) {
+	precision := uint8(bits.TrailingZeros(uint(len(registers))))
+	if precision < 4 || precision > 16 || len(registers) != 1<<precision {
+		return nil, errors.New("hll: invalid register count")
+	}
+	return &Sketch{precision: precision, registers: registers}, nil
+}
+
+// Registers renvoie les registres du sketch, à enregistrer tels quels.
+func (s *Sketch) Registers() []byte {
+	return s.registers
+}
+
+// Merge ajoute au sketch les éléments de other, qui doit être de même précision.
+func (s *Sketch) Merge(other *Sketch) error {
+	if other.precision != s.precision {
+		return ErrPrecisionMismatch
+	}
+	for i, r := range other.registers {
+		s.registers[i] = max(s.registers[i], r)
+	}
+	return nil
+}
+
+// Estimate renvoie l'estimation du nombre d'éléments distincts ajoutés.
+func (s *Sketch) Estimate() uint64 {
+	m := float64(len(s.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(m) * m * m / sum
+
+	// Correction pour les petites cardinalités (linear counting).
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// alpha est la constante de correction du biais pour m registres.
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
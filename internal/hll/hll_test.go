@@ -0,0 +1,69 @@
+package hll
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// addRandom ajoute n éléments distincts tirés d'un générateur déterministe.
+func addRandom(s *Sketch, seed uint64, n int) {
+	r := rand.New(rand.NewPCG(seed, seed))
+	for range n {
+		s.Add(r.Uint64())
+	}
+}
+
+func TestEstimateStaysWithinExpectedError(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 50000} {
+		s := New(12)
+		addRandom(s, uint64(n), n)
+		// 4096 registres : erreur type d'environ 1,6 %, tolérance de 5 %.
+		if got := float64(s.Estimate()); math.Abs(got-float64(n)) > 0.05*float64(n)+1 {
+			t.Errorf("Estimate() = %.0f for %d distinct elements", got, n)
+		}
+	}
+}
+
+func TestDuplicatesDoNotChangeEstimate(t *testing.T) {
+	s := New(12)
+	addRandom(s, 1, 500)
+	before := s.Estimate()
+	addRandom(s, 1, 500)
+	if after := s.Estimate(); after != before {
+		t.Errorf("Estimate() = %d after re-adding the same elements, want %d", after, before)
+	}
+}
+
+func TestMergeEstimatesTheUnion(t *testing.T) {
+	a, b := New(12), New(12)
+	addRandom(a, 1, 3000)
+	addRandom(b, 2, 3000)
+	addRandom(b, 1, 1000) // Éléments communs aux deux sketches.
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if got := float64(a.Estimate()); math.Abs(got-6000) > 300 {
+		t.Errorf("Estimate() after merge = %.0f, want about 6000", got)
+	}
+	if err := a.Merge(New(10)); err != ErrPrecisionMismatch {
+		t.Errorf("Merge with another precision = %v, want ErrPrecisionMismatch", err)
+	}
+}
+
+func TestFromRegistersRestoresSketch(t *testing.T) {
+	s := New(10)
+	addRandom(s, 3, 2000)
+	restored, err := FromRegisters(append([]byte(nil), s.Registers()...))
+	if err != nil {
+		t.Fatalf("FromRegisters: %v", err)
+	}
+	if restored.Estimate() != s.Estimate() {
+		t.Errorf("restored estimate = %d, want %d", restored.Estimate(), s.Estimate())
+	}
+	for _, size := range []int{0, 8, 1000, 1 << 17} {
+		if _, err := FromRegisters(make([]byte, size)); err == nil {
+			t.Errorf("FromRegisters accepted %d registers", size)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// defaultSlowQueryThreshold est la durée au-delà de laquelle une requête SQL est signalée comme lente.
+const defaultSlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger transmet les journaux de GORM à slog, avec l'identifiant de requête du contexte.
+// Les requêtes en erreur sont journalisées en ERROR, les requêtes lentes en WARN et les autres en DEBUG.
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger crée un logger GORM fondé sur le logger slog par défaut.
+func NewGormLogger() *GormLogger {
+	return &GormLogger{SlowThreshold: defaultSlowQueryThreshold}
+}
+
+// LogMode est sans effet : le niveau est celui du logger slog.
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info journalise un message d'information de GORM.
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Warn journalise un avertissement de GORM.
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Error journalise une erreur de GORM.
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Trace journalise une requête SQL exécutée. Un enregistrement introuvable n'est pas une erreur.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "sql query failed", "sql", sql, "rows", rows, "duration", elapsed, Err(err))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "slow sql query", "sql", sql, "rows", rows, "duration", elapsed)
+	case slog.Default().Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		slog.DebugContext(ctx, "sql query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
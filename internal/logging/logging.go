@@ -0,0 +1,88 @@
+// Package logging configure la journalisation structurée (log/slog) du service et
+// propage l'identifiant de requête (X-Request-ID) dans les contextes.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats de sortie acceptés.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attributs communs des journaux.
+const (
+	KeyRequestID = "request_id"
+	KeyLinkID    = "link_id"
+	KeyShortCode = "short_code"
+	KeyError     = "error"
+)
+
+// requestIDKey est la clé de contexte de l'identifiant de requête.
+type requestIDKey struct{}
+
+// New crée un logger écrivant dans w au format et au niveau donnés.
+// Chaque entrée journalisée avec un contexte porteur d'un identifiant de requête l'inclut automatiquement.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// WithRequestID renvoie un contexte portant l'identifiant de requête id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID renvoie l'identifiant de requête porté par ctx, ou une chaîne vide.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Err renvoie l'attribut standard d'une erreur.
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// contextHandler ajoute aux entrées les attributs portés par le contexte.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle ajoute l'identifiant de requête du contexte avant de transmettre l'entrée.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs conserve l'enrichissement par le contexte sur les loggers dérivés.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup conserve l'enrichissement par le contexte sur les loggers dérivés.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
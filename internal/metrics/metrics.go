@@ -0,0 +1,139 @@
+// Package metrics déclare les métriques Prometheus du service, exposées au format texte sur /metrics.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace préfixe toutes les métriques du service.
+const namespace = "urlshortener"
+
+// Issues d'un événement de clic qui n'a pas pu être placé dans le channel des workers.
+const (
+	OverflowSpooled = "spooled"
+	OverflowDropped = "dropped"
+)
+
+var (
+	// RedirectDuration mesure la durée de traitement des redirections, par code HTTP.
+	RedirectDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redirect_duration_seconds",
+		Help:      "Duration of short link redirect requests.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"status"})
+
+	// RedirectsTotal compte les redirections, par code HTTP.
+	RedirectsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short link redirect requests by HTTP status.",
+	}, []string{"status"})
+
+	// LinksCreatedTotal compte les liens courts créés.
+	LinksCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "links_created_total",
+		Help:      "Short links created.",
+	})
+
+	// ClickEventsOverflowTotal compte les événements de clic refusés par le channel plein, selon leur issue.
+	ClickEventsOverflowTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_overflow_total",
+		Help:      "Click events that did not fit in the click channel, by outcome (spooled or dropped).",
+	}, []string{"outcome"})
+
+	// ClicksPersistedTotal compte les clics enregistrés en base par les workers.
+	ClicksPersistedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clicks_persisted_total",
+		Help:      "Clicks saved to the database by the click workers.",
+	})
+
+	// ClickBatchSize mesure la taille des lots de clics écrits par les workers.
+	ClickBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "click_batch_size",
+		Help:      "Number of clicks per batch written by the click workers.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	// ClickFlushDuration mesure la durée des écritures groupées de clics, échecs compris.
+	ClickFlushDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "click_flush_duration_seconds",
+		Help:      "Duration of click batch inserts by the click workers.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	})
+
+	// WorkerInsertErrorsTotal compte les échecs d'écriture des workers, par type (lot ou clic isolé).
+	WorkerInsertErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_insert_errors_total",
+		Help:      "Click worker insert failures, by kind (batch or single).",
+	}, []string{"kind"})
+
+	// MonitorChecksTotal compte les vérifications du moniteur d'URLs, par état constaté.
+	MonitorChecksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "monitor_checks_total",
+		Help:      "URL monitor checks by resulting state.",
+	}, []string{"state"})
+
+	// MonitorRunDuration mesure la durée des passes complètes du moniteur d'URLs.
+	MonitorRunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "monitor_run_duration_seconds",
+		Help:      "Duration of complete URL monitor runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12),
+	})
+
+	// MonitorRunsSkippedTotal compte les passes du moniteur sautées car la précédente n'était pas terminée.
+	MonitorRunsSkippedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "monitor_runs_skipped_total",
+		Help:      "URL monitor runs skipped because the previous run was still in progress.",
+	})
+
+	// DownDestinationVisitsTotal compte les visites de liens dont l'URL longue est déclarée inaccessible, par politique de secours.
+	DownDestinationVisitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "down_destination_visits_total",
+		Help:      "Visits to links whose destination is declared down, by failover policy.",
+	}, []string{"policy"})
+
+	// NotificationsTotal compte les notifications de changement d'état, par résultat (sent, failed, dropped ou suppressed).
+	NotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Link state change notifications by outcome (sent, failed, dropped or suppressed).",
+	}, []string{"outcome"})
+)
+
+// ObserveRedirect enregistre une redirection terminée avec le code status.
+func ObserveRedirect(status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	RedirectsTotal.WithLabelValues(code).Inc()
+	RedirectDuration.WithLabelValues(code).Observe(duration.Seconds())
+}
+
+// RegisterClickChannel expose l'occupation et la capacité du channel des événements de clic.
+// Elle ne doit être appelée qu'une fois.
+func RegisterClickChannel(ch chan models.ClickEvent) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "click_channel_depth",
+		Help:      "Click events waiting in the click channel.",
+	}, func() float64 { return float64(len(ch)) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "click_channel_capacity",
+		Help:      "Capacity of the click channel.",
+	}, func() float64 { return float64(cap(ch)) })
+}
@@ -4,12 +4,31 @@ import "time"
 
 // Click représente un événement de clic sur un lien raccourci.
 type Click struct {
-	ID        uint `gorm:"primaryKey"`
-	LinkID    uint `gorm:"index"`
-	Link      Link `gorm:"foreignKey:LinkID"`
-	Timestamp time.Time
-	UserAgent string `gorm:"size:255"`
-	IPAddress string `gorm:"size:50"`
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"index"`
+	Link      Link      `gorm:"foreignKey:LinkID"`
+	Timestamp time.Time `gorm:"index"`
+	UserAgent string    `gorm:"size:255"` // Vide dès que l'empreinte de visiteur est calculée.
+	IPAddress string    `gorm:"size:50"`
+
+	// Informations extraites du User-Agent lors de l'ingestion.
+	Browser        string `gorm:"size:50"`
+	BrowserVersion string `gorm:"size:50"`
+	OS             string `gorm:"size:50"`
+	DeviceType     string `gorm:"size:20"` // desktop, mobile, tablet ou unknown
+	IsBot          bool
+
+	// Provenance du clic, vide pour un accès direct.
+	Referrer     string `gorm:"type:text"`
+	ReferrerHost string `gorm:"size:255"`
+
+	// Géolocalisation issue de la base GeoIP locale, vide si elle n'est pas configurée.
+	Country string `gorm:"size:2"` // Code ISO 3166-1 alpha-2.
+	Region  string `gorm:"size:100"`
+	City    string `gorm:"size:100"`
+
+	// Empreinte salée de l'IP et du User-Agent, propre à la journée du clic.
+	VisitorHash string `gorm:"size:64;index"`
 }
 
 func (Click) TableName() string {
@@ -21,4 +40,19 @@ type ClickEvent struct {
 	Timestamp time.Time `json:"timestamp"`
 	UserAgent string    `json:"user_agent"`
 	IPAddress string    `json:"ip_address"`
+	Referrer  string    `json:"referrer"`
+	// Ack, s'il est renseigné, est appelé par les workers une fois le clic traité (enregistré ou rejeté).
+	Ack func() `json:"-"`
+}
+
+// ClickSlot représente le nombre de clics d'un lien sur un créneau commençant à Start.
+type ClickSlot struct {
+	Start  time.Time
+	Clicks int
+}
+
+// BreakdownEntry représente le nombre de clics associés à une valeur d'une dimension (navigateur, OS...).
+type BreakdownEntry struct {
+	Value  string `json:"value"`
+	Clicks int    `json:"clicks"`
 }
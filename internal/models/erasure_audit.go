@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Critères des demandes d'effacement de clics.
+const (
+	ErasureByIP          = "ip"
+	ErasureByVisitorHash = "visitor_hash"
+)
+
+// ErasureAudit trace une suppression de clics effectuée sur demande d'effacement.
+// L'identifiant de la personne n'y est pas conservé, même sous forme d'empreinte : une empreinte d'adresse IP
+// se retrouve en parcourant l'espace IPv4. La demande est rattachée à l'audit par sa référence (Reason).
+type ErasureAudit struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Criterion string    `gorm:"size:20;not null" json:"criterion"` // ip ou visitor_hash
+	// AnonymizationMode est le mode d'anonymisation des IP en vigueur lors de l'effacement.
+	AnonymizationMode string `gorm:"size:10" json:"anonymization_mode"`
+	Actor             string `gorm:"size:100" json:"actor"`
+	Reason            string `gorm:"type:text" json:"reason"`
+	DeletedCount      int64  `json:"deleted_count"`
+	// SpooledCount est le nombre d'événements en attente dans le spool supprimés avant leur enregistrement.
+	SpooledCount int64 `json:"spooled_count"`
+}
+
+func (ErasureAudit) TableName() string {
+	return "erasure_audits"
+}
@@ -1,21 +1,47 @@
 package models
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Politiques de redirection d'un lien dont l'URL longue est déclarée inaccessible.
+const (
+	// FailoverKeep continue de rediriger vers l'URL longue.
+	FailoverKeep = "keep"
+	// FailoverRedirectFallback redirige vers l'URL de secours du lien.
+	FailoverRedirectFallback = "redirect_fallback"
+	// FailoverUnavailablePage affiche une page indiquant que la destination est indisponible.
+	FailoverUnavailablePage = "unavailable_page"
+)
+
 // Link représente un lien raccourci dans la base de données.
 type Link struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	ShortCode string         `gorm:"unique;not null;size:6;index" json:"short_code"`
+	ShortCode string         `gorm:"unique;not null;size:32;index" json:"short_code"`
 	LongURL   string         `gorm:"not null;type:text" json:"long_url"`
+	Host      string         `gorm:"size:255;index" json:"-"` // Hôte de LongURL (voir URLHost), pour le filtre par domaine.
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
+	ExpiresAt *time.Time     `gorm:"index" json:"expires_at,omitempty"` // nil : le lien n'expire jamais
+	MaxClicks int            `gorm:"default:0" json:"max_clicks"`       // 0 : nombre de clics illimité
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// État de l'URL longue constaté par le moniteur, date de sa dernière vérification
+	// et nombre de vérifications en échec consécutives.
+	HealthStatus        string     `gorm:"size:16;not null;default:unknown" json:"health_status"`
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+
+	// Comportement de la redirection lorsque le moniteur a déclaré l'URL longue inaccessible.
+	// L'URL de secours n'est jamais vérifiée : les visiteurs y sont redirigés même si elle est elle aussi inaccessible.
+	FallbackURL    string `gorm:"type:text" json:"fallback_url,omitempty"`
+	FailoverPolicy string `gorm:"size:24;not null;default:keep" json:"failover_policy"`
+
 	// Relation un-à-plusieurs avec les clics.
 	Clicks []Click `gorm:"foreignKey:LinkID" json:"clicks,omitempty"`
 }
@@ -23,3 +49,18 @@ type Link struct {
 func (Link) TableName() string {
 	return "links"
 }
+
+// URLHost renvoie l'hôte d'une URL en minuscules, sans identifiants ni port ; vide si l'URL est invalide.
+func URLHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// LinkWithClickCount associe un lien à son nombre total de clics, pour les listes triables.
+type LinkWithClickCount struct {
+	Link
+	ClickCount int `json:"click_count"`
+}
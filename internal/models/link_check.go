@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// États de santé d'un lien constatés par le moniteur d'URLs.
+const (
+	LinkHealthUnknown = "unknown"
+	LinkHealthUp      = "up"
+	LinkHealthDown    = "down"
+)
+
+// Classes d'erreur d'une vérification en échec.
+const (
+	CheckErrorInvalidURL       = "invalid_url"
+	CheckErrorDNS              = "dns"
+	CheckErrorTLS              = "tls"
+	CheckErrorTimeout          = "timeout"
+	CheckErrorConnection       = "connection"
+	CheckErrorHTTP4xx          = "http_4xx"
+	CheckErrorHTTP5xx          = "http_5xx"
+	CheckErrorRedirectLoop     = "redirect_loop"
+	CheckErrorTooManyRedirects = "too_many_redirects"
+)
+
+// LinkCheck enregistre le résultat d'une vérification de l'URL longue d'un lien par le moniteur.
+type LinkCheck struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	LinkID     uint      `gorm:"index:idx_link_checks_link_checked_at,priority:1;not null" json:"link_id"`
+	CheckedAt  time.Time `gorm:"index:idx_link_checks_link_checked_at,priority:2;index;not null" json:"checked_at"`
+	Accessible bool      `json:"accessible"`
+	StatusCode int       `json:"status_code,omitempty"` // 0 : aucune réponse HTTP reçue
+	LatencyMs  int64     `json:"latency_ms"`
+	ErrorClass string    `gorm:"size:32" json:"error_class,omitempty"` // vide si l'URL est accessible
+	// Method est la méthode de la dernière requête : GET si le serveur a refusé HEAD.
+	Method string `gorm:"size:8" json:"method,omitempty"`
+	// RedirectChain liste les URLs successives suivies depuis l'URL longue ; vide sans redirection.
+	RedirectChain []string `gorm:"serializer:json;type:text" json:"redirect_chain,omitempty"`
+	// LinkStatus est l'état du lien retenu après cette vérification, compte tenu du seuil d'échecs consécutifs.
+	LinkStatus string `gorm:"size:16" json:"link_status,omitempty"`
+}
+
+func (LinkCheck) TableName() string {
+	return "link_checks"
+}
+
+// HealthStatus renvoie l'état de santé correspondant au seul résultat de la vérification.
+func (c LinkCheck) HealthStatus() string {
+	if c.Accessible {
+		return LinkHealthUp
+	}
+	return LinkHealthDown
+}
+
+// DeclaredStatus renvoie l'état du lien retenu après la vérification, ou à défaut (vérifications antérieures
+// au seuil d'échecs consécutifs) celui correspondant à son résultat.
+func (c LinkCheck) DeclaredStatus() string {
+	if c.LinkStatus != "" {
+		return c.LinkStatus
+	}
+	return c.HealthStatus()
+}
@@ -0,0 +1,40 @@
+package models
+
+// ClickRollup agrège les clics bruts purgés d'un lien pour une journée (UTC).
+type ClickRollup struct {
+	LinkID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	Day     string `gorm:"primaryKey;size:10"` // Format AAAA-MM-JJ.
+	Clicks  int    `gorm:"not null"`
+	Uniques int    `gorm:"not null"` // Empreintes de visiteurs distinctes de la journée.
+}
+
+func (ClickRollup) TableName() string {
+	return "click_rollups"
+}
+
+// ClickDimensionRollup agrège les clics bruts purgés d'un lien pour une journée (UTC)
+// et une valeur d'une dimension (pays, appareil...).
+type ClickDimensionRollup struct {
+	LinkID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Day       string `gorm:"primaryKey;size:10"`
+	Dimension string `gorm:"primaryKey;size:20"`
+	Value     string `gorm:"primaryKey;size:255"`
+	Clicks    int    `gorm:"not null"`
+}
+
+func (ClickDimensionRollup) TableName() string {
+	return "click_dimension_rollups"
+}
+
+// VisitorSketch est le sketch HyperLogLog des empreintes de visiteurs d'un lien pour une journée (UTC),
+// complété à chaque écriture de clics. Il ne contient que des maxima de rangs : un visiteur effacé
+// n'en est pas retiré, mais ne peut pas non plus en être extrait.
+type VisitorSketch struct {
+	LinkID    uint   `gorm:"primaryKey;autoIncrement:false"`
+	Day       string `gorm:"primaryKey;size:10"`
+	Registers []byte `gorm:"not null"`
+}
+
+func (VisitorSketch) TableName() string {
+	return "visitor_sketches"
+}
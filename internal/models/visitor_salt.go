@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// VisitorSalt est le sel aléatoire d'une journée (UTC) utilisé pour calculer les empreintes de visiteurs.
+// Les sels des jours passés sont supprimés : les empreintes ne peuvent alors plus être recalculées.
+type VisitorSalt struct {
+	Day       string `gorm:"primaryKey;size:10"` // Format AAAA-MM-JJ.
+	Salt      []byte `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (VisitorSalt) TableName() string {
+	return "visitor_salts"
+}
+
+// DailyUniqueVisitors représente le nombre de visiteurs uniques d'un lien sur une journée (UTC).
+type DailyUniqueVisitors struct {
+	Day      string `json:"day"`
+	Visitors int    `json:"visitors"`
+}
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// hostLimiter borne le nombre de vérifications simultanées et leur cadence pour chaque hôte,
+// afin de ne pas solliciter excessivement un même site. Un limiteur sert pour une seule passe.
+type hostLimiter struct {
+	concurrency int
+	spacing     time.Duration // Délai minimal entre deux vérifications d'un même hôte ; 0 sans limite.
+	mu          sync.Mutex
+	hosts       map[string]*hostSlot
+}
+
+// hostSlot est l'état du limiteur pour un hôte.
+type hostSlot struct {
+	sem  chan struct{}
+	next time.Time // Heure à partir de laquelle la prochaine vérification peut commencer.
+}
+
+// newHostLimiter crée un limiteur autorisant concurrency vérifications simultanées
+// et au plus ratePerSecond vérifications par seconde par hôte (0 : pas de limite de cadence).
+func newHostLimiter(concurrency int, ratePerSecond float64) *hostLimiter {
+	l := &hostLimiter{
+		concurrency: max(1, concurrency),
+		hosts:       make(map[string]*hostSlot),
+	}
+	if ratePerSecond > 0 {
+		l.spacing = time.Duration(float64(time.Second) / ratePerSecond)
+	}
+	return l
+}
+
+// acquire attend qu'une vérification de host soit autorisée et renvoie la fonction qui libère la place.
+// Elle renvoie l'erreur de ctx si ctx est annulé avant.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	slot := l.slot(host)
+	select {
+	case slot.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-slot.sem }
+
+	if l.spacing > 0 {
+		l.mu.Lock()
+		at := slot.next
+		if now := time.Now(); at.Before(now) {
+			at = now
+		}
+		slot.next = at.Add(l.spacing)
+		l.mu.Unlock()
+
+		if wait := time.Until(at); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return release, nil
+}
+
+// slot renvoie l'état de host, créé au premier accès.
+func (l *hostLimiter) slot(host string) *hostSlot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{sem: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = slot
+	}
+	return slot
+}
+
+// linkHost renvoie l'hôte de l'URL longue d'un lien, en minuscules ; vide si l'URL est invalide.
+func linkHost(link models.Link) string {
+	u, err := url.Parse(link.LongURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// interleaveByHost ordonne les liens en alternant les hôtes, dans un ordre d'hôtes aléatoire,
+// pour que les workers ne se retrouvent pas tous bloqués par les limites d'un même hôte.
+func interleaveByHost(links []models.Link) []models.Link {
+	byHost := make(map[string][]models.Link)
+	var hosts []string
+	for _, link := range links {
+		host := linkHost(link)
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], link)
+	}
+	rand.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+
+	ordered := make([]models.Link, 0, len(links))
+	for round := 0; len(ordered) < len(links); round++ {
+		for _, host := range hosts {
+			if group := byHost[host]; round < len(group) {
+				ordered = append(ordered, group[round])
+			}
+		}
+	}
+	return ordered
+}
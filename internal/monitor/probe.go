@@ -0,0 +1,176 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// userAgent identifie le moniteur auprès des sites vérifiés ; certains refusent le User-Agent par défaut de Go.
+const userAgent = "urlshortener-monitor/1.0"
+
+// checkUrl vérifie l'accessibilité de l'URL longue d'un lien en suivant ses redirections une à une,
+// pour enregistrer la chaîne suivie et détecter les boucles. Chaque étape est vérifiée par une requête HEAD,
+// remplacée par un GET partiel si le serveur refuse HEAD. La latence couvre la chaîne complète.
+func (m *UrlMonitor) checkUrl(ctx context.Context, link models.Link) models.LinkCheck {
+	check := models.LinkCheck{LinkID: link.ID, CheckedAt: time.Now()}
+
+	target, err := url.Parse(link.LongURL)
+	if err != nil || !isHTTPURL(target) {
+		slog.Warn("invalid monitored url", logging.KeyLinkID, link.ID, "url", link.LongURL, logging.Err(err))
+		check.ErrorClass = models.CheckErrorInvalidURL
+		return check
+	}
+	start := time.Now()
+	m.followRedirects(ctx, link, target, &check)
+	check.LatencyMs = time.Since(start).Milliseconds()
+	return check
+}
+
+// followRedirects vérifie target puis les URLs vers lesquelles il redirige et reporte le résultat dans check.
+func (m *UrlMonitor) followRedirects(ctx context.Context, link models.Link, target *url.URL, check *models.LinkCheck) {
+	visited := map[string]bool{target.String(): true}
+	for {
+		resp, err := m.fetch(ctx, target.String())
+		if err != nil {
+			slog.Debug("monitored url unreachable", logging.KeyLinkID, link.ID, "url", target.String(), logging.Err(err))
+			check.ErrorClass = classifyError(err)
+			return
+		}
+		check.Method = resp.Request.Method
+		check.StatusCode = resp.StatusCode
+
+		location, err := resp.Location()
+		if !isRedirect(resp.StatusCode) || err != nil {
+			classifyStatus(check)
+			return
+		}
+		next := location.String()
+		switch {
+		case !isHTTPURL(location):
+			check.ErrorClass = models.CheckErrorInvalidURL
+		case visited[next]:
+			check.ErrorClass = models.CheckErrorRedirectLoop
+		case len(check.RedirectChain) >= m.opts.MaxRedirects:
+			check.ErrorClass = models.CheckErrorTooManyRedirects
+		}
+		check.RedirectChain = append(check.RedirectChain, next)
+		if check.ErrorClass != "" {
+			slog.Debug("monitored url redirect chain rejected", logging.KeyLinkID, link.ID,
+				"chain", check.RedirectChain, "error_class", check.ErrorClass)
+			return
+		}
+		visited[next] = true
+		target = location
+	}
+}
+
+// fetch envoie une requête HEAD vers rawURL, ou un GET limité au premier octet si le serveur refuse HEAD
+// (405, 501, ou 403 renvoyé par certains sites à toute requête HEAD). Le corps de la réponse est fermé ;
+// ses en-têtes restent disponibles.
+func (m *UrlMonitor) fetch(ctx context.Context, rawURL string) (*http.Response, error) {
+	resp, err := m.do(ctx, http.MethodHead, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusForbidden:
+		return m.do(ctx, http.MethodGet, rawURL)
+	}
+	return resp, nil
+}
+
+// do envoie une requête sans suivre les redirections et ferme le corps de la réponse.
+func (m *UrlMonitor) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// newProbeClient crée le client HTTP des vérifications. Les redirections ne sont pas suivies automatiquement :
+// followRedirects les suit elle-même.
+func newProbeClient() *http.Client {
+	return &http.Client{
+		Timeout: checkTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isHTTPURL indique si u est une URL absolue http ou https.
+func isHTTPURL(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isRedirect indique si code est un code de redirection à suivre.
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// classifyStatus reporte dans check l'accessibilité correspondant au code de la réponse finale.
+// Les codes 2xx ou 3xx indiquent une URL accessible, de même que 416 : la ressource existe mais est vide.
+func classifyStatus(check *models.LinkCheck) {
+	code := check.StatusCode
+	check.Accessible = code >= 200 && code < 400 || code == http.StatusRequestedRangeNotSatisfiable
+	switch {
+	case check.Accessible:
+	case code >= 500:
+		check.ErrorClass = models.CheckErrorHTTP5xx
+	default:
+		check.ErrorClass = models.CheckErrorHTTP4xx
+	}
+}
+
+// classifyError renvoie la classe d'erreur d'une requête qui n'a pas obtenu de réponse.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return models.CheckErrorDNS
+	case isTLSError(err):
+		return models.CheckErrorTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.CheckErrorTimeout
+	default:
+		return models.CheckErrorConnection
+	}
+}
+
+// isTLSError indique si err provient de l'établissement de la connexion TLS (certificat refusé, alerte, protocole).
+func isTLSError(err error) bool {
+	var verificationErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verificationErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
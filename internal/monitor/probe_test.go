@@ -0,0 +1,229 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		code       int
+		accessible bool
+		errorClass string
+	}{
+		{http.StatusOK, true, ""},
+		{http.StatusNoContent, true, ""},
+		{http.StatusPartialContent, true, ""},
+		{http.StatusNotModified, true, ""},
+		{http.StatusRequestedRangeNotSatisfiable, true, ""},
+		{http.StatusBadRequest, false, models.CheckErrorHTTP4xx},
+		{http.StatusNotFound, false, models.CheckErrorHTTP4xx},
+		{http.StatusGone, false, models.CheckErrorHTTP4xx},
+		{http.StatusInternalServerError, false, models.CheckErrorHTTP5xx},
+		{http.StatusServiceUnavailable, false, models.CheckErrorHTTP5xx},
+		{http.StatusContinue, false, models.CheckErrorHTTP4xx},
+	}
+	for _, tt := range tests {
+		check := models.LinkCheck{StatusCode: tt.code}
+		classifyStatus(&check)
+		if check.Accessible != tt.accessible || check.ErrorClass != tt.errorClass {
+			t.Errorf("classifyStatus(%d) = %t, %q, want %t, %q", tt.code, check.Accessible, check.ErrorClass, tt.accessible, tt.errorClass)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"dns", &url.Error{Op: "Head", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}}, models.CheckErrorDNS},
+		{"deadline", fmt.Errorf("head: %w", context.DeadlineExceeded), models.CheckErrorTimeout},
+		{"network timeout", &url.Error{Op: "Head", Err: timeoutError{}}, models.CheckErrorTimeout},
+		{"refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, models.CheckErrorConnection},
+		{"other", errors.New("unexpected EOF"), models.CheckErrorConnection},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// timeoutError est une erreur réseau signalant un délai dépassé.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRedirect(t *testing.T) {
+	for code, want := range map[int]bool{
+		http.StatusMovedPermanently:  true,
+		http.StatusFound:             true,
+		http.StatusSeeOther:          true,
+		http.StatusTemporaryRedirect: true,
+		http.StatusPermanentRedirect: true,
+		http.StatusMultipleChoices:   false,
+		http.StatusNotModified:       false,
+		http.StatusOK:                false,
+	} {
+		if got := isRedirect(code); got != want {
+			t.Errorf("isRedirect(%d) = %t, want %t", code, got, want)
+		}
+	}
+}
+
+func TestIsHTTPURL(t *testing.T) {
+	for raw, want := range map[string]bool{
+		"https://example.com/path": true,
+		"http://example.com:8080":  true,
+		"ftp://example.com":        false,
+		"mailto:someone@example":   false,
+		"/relative/path":           false,
+		"https://":                 false,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", raw, err)
+		}
+		if got := isHTTPURL(u); got != want {
+			t.Errorf("isHTTPURL(%q) = %t, want %t", raw, got, want)
+		}
+	}
+}
+
+// newProbeServer démarre un serveur de test :
+//   - /ok répond 200, /missing 404, /down 503, /empty 416 ;
+//   - /nohead refuse HEAD (405) et accepte le GET partiel (206) ;
+//   - /to/<chemin> redirige vers <chemin>, /hop/<n> redirige vers /hop/<n+1> ;
+//   - /ftp redirige vers une URL ftp.
+func newProbeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != userAgent {
+			http.Error(w, "unexpected user agent", http.StatusBadRequest)
+			return
+		}
+		switch path := r.URL.Path; {
+		case path == "/ok":
+		case path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case path == "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case path == "/empty":
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		case path == "/nohead":
+			if r.Method == http.MethodHead || r.Header.Get("Range") != "bytes=0-0" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusPartialContent)
+		case strings.HasPrefix(path, "/to/"):
+			http.Redirect(w, r, strings.TrimPrefix(path, "/to"), http.StatusFound)
+		case strings.HasPrefix(path, "/hop/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(path, "/hop/"))
+			http.Redirect(w, r, fmt.Sprintf("/hop/%d", n+1), http.StatusMovedPermanently)
+		case path == "/loop-a":
+			http.Redirect(w, r, "/loop-b", http.StatusTemporaryRedirect)
+		case path == "/loop-b":
+			http.Redirect(w, r, "/loop-a", http.StatusPermanentRedirect)
+		case path == "/ftp":
+			http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckUrl(t *testing.T) {
+	srv := newProbeServer(t)
+	m := NewUrlMonitor(nil, nil, Options{MaxRedirects: 3})
+
+	tests := []struct {
+		path       string
+		method     string
+		status     int
+		accessible bool
+		errorClass string
+		chain      []string
+	}{
+		{"/ok", http.MethodHead, http.StatusOK, true, "", nil},
+		{"/nohead", http.MethodGet, http.StatusPartialContent, true, "", nil},
+		{"/empty", http.MethodHead, http.StatusRequestedRangeNotSatisfiable, true, "", nil},
+		{"/missing", http.MethodHead, http.StatusNotFound, false, models.CheckErrorHTTP4xx, nil},
+		{"/down", http.MethodHead, http.StatusServiceUnavailable, false, models.CheckErrorHTTP5xx, nil},
+		{"/to/to/ok", http.MethodHead, http.StatusOK, true, "", []string{"/to/ok", "/ok"}},
+		{"/to/missing", http.MethodHead, http.StatusNotFound, false, models.CheckErrorHTTP4xx, []string{"/missing"}},
+		{"/loop-a", http.MethodHead, http.StatusPermanentRedirect, false, models.CheckErrorRedirectLoop, []string{"/loop-b", "/loop-a"}},
+		{"/hop/0", http.MethodHead, http.StatusMovedPermanently, false, models.CheckErrorTooManyRedirects, []string{"/hop/1", "/hop/2", "/hop/3", "/hop/4"}},
+		{"/ftp", http.MethodHead, http.StatusFound, false, models.CheckErrorInvalidURL, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			check := m.checkUrl(context.Background(), models.Link{ID: 1, LongURL: srv.URL + tt.path})
+			if check.Method != tt.method || check.StatusCode != tt.status {
+				t.Errorf("request = %s with status %d, want %s with %d", check.Method, check.StatusCode, tt.method, tt.status)
+			}
+			if check.Accessible != tt.accessible || check.ErrorClass != tt.errorClass {
+				t.Errorf("result = %t, %q, want %t, %q", check.Accessible, check.ErrorClass, tt.accessible, tt.errorClass)
+			}
+			if tt.path == "/ftp" {
+				if len(check.RedirectChain) != 1 || check.RedirectChain[0] != "ftp://example.com/file" {
+					t.Errorf("redirect chain = %v, want the ftp url", check.RedirectChain)
+				}
+				return
+			}
+			if len(check.RedirectChain) != len(tt.chain) {
+				t.Fatalf("redirect chain = %v, want %v", check.RedirectChain, tt.chain)
+			}
+			for i, path := range tt.chain {
+				if check.RedirectChain[i] != srv.URL+path {
+					t.Errorf("redirect %d = %s, want %s", i, check.RedirectChain[i], srv.URL+path)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckUrlFailures(t *testing.T) {
+	m := NewUrlMonitor(nil, nil, Options{})
+
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer tlsSrv.Close()
+	closed := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		url  string
+		want string
+	}{
+		{"unsupported scheme", context.Background(), "ftp://example.com/file", models.CheckErrorInvalidURL},
+		{"relative url", context.Background(), "/path", models.CheckErrorInvalidURL},
+		{"untrusted certificate", context.Background(), tlsSrv.URL, models.CheckErrorTLS},
+		{"connection refused", context.Background(), closed.URL, models.CheckErrorConnection},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := m.checkUrl(tt.ctx, models.Link{ID: 1, LongURL: tt.url})
+			if check.Accessible || check.ErrorClass != tt.want {
+				t.Errorf("result = %t, %q, want false, %q", check.Accessible, check.ErrorClass, tt.want)
+			}
+		})
+	}
+}
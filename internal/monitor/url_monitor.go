@@ -1,107 +1,329 @@
 package monitor
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/notify"
 	"github.com/axellelanca/urlshortener/internal/repository"
 )
 
+// checkTimeout borne la durée de chaque requête d'une vérification.
+const checkTimeout = 5 * time.Second
+
+// Valeurs par défaut des options du moniteur.
+const (
+	defaultWorkers            = 10
+	defaultPerHostConcurrency = 2
+	defaultMaxRedirects       = 10
+)
+
+// Options paramètre le moniteur d'URLs.
+type Options struct {
+	// Interval est l'intervalle moyen entre deux passes de vérification.
+	Interval time.Duration
+	// Jitter est la variation aléatoire de l'intervalle, en fraction de celui-ci (0,1 : ±10 %).
+	Jitter float64
+	// CheckRetention est la durée de conservation des vérifications ; 0 pour les conserver indéfiniment.
+	CheckRetention time.Duration
+	// Workers est le nombre de vérifications menées en parallèle.
+	Workers int
+	// PerHostConcurrency est le nombre maximal de vérifications simultanées d'un même hôte.
+	PerHostConcurrency int
+	// PerHostRate est le nombre maximal de vérifications par seconde d'un même hôte ; 0 sans limite.
+	PerHostRate float64
+	// FailureThreshold est le nombre de vérifications en échec consécutives à partir duquel un lien est déclaré
+	// inaccessible (1 par défaut : dès le premier échec).
+	FailureThreshold int
+	// MaxRedirects est le nombre maximal de redirections suivies lors d'une vérification.
+	MaxRedirects int
+	// Notifier reçoit les changements d'état des liens ; nil pour se contenter des logs.
+	Notifier notify.Notifier
+}
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
+// Chaque vérification est enregistrée et l'état de santé courant est conservé sur le lien,
+// ce qui permet de détecter les changements d'état d'un redémarrage à l'autre.
+//
+// Les URLs sont vérifiées en parallèle par un nombre borné de workers, dans le respect des limites par hôte.
+// Une passe qui n'est pas terminée à l'échéance suivante n'est pas doublée : la passe suivante est sautée.
 type UrlMonitor struct {
-	linkRepo    repository.LinkRepository
-	interval    time.Duration
-	knownStates map[uint]bool // État connu de chaque URL: map[LinkID]estAccessible
-	mu          sync.Mutex
+	linkRepo  repository.LinkRepository
+	checkRepo repository.LinkCheckRepository
+	opts      Options
+	client    *http.Client
+	running   atomic.Bool  // Indique qu'une passe est en cours.
+	heartbeat atomic.Int64 // Heure du dernier signal d'activité en nanosecondes Unix ; 0 hors exécution.
 }
 
 // NewUrlMonitor crée une nouvelle instance de UrlMonitor.
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, checkRepo repository.LinkCheckRepository, opts Options) *UrlMonitor {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.PerHostConcurrency <= 0 {
+		opts.PerHostConcurrency = defaultPerHostConcurrency
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = defaultMaxRedirects
+	}
+	opts.Jitter = min(max(opts.Jitter, 0), 1)
 	return &UrlMonitor{
-		linkRepo:    linkRepo,
-		interval:    interval,
-		knownStates: make(map[uint]bool),
-		mu:          sync.Mutex{},
+		linkRepo:  linkRepo,
+		checkRepo: checkRepo,
+		opts:      opts,
+		client:    newProbeClient(),
+	}
+}
+
+// Start lance la surveillance périodique des URLs jusqu'à l'annulation de ctx, puis attend la fin de la passe en cours.
+// Elle est destinée à être exécutée dans une goroutine.
+func (m *UrlMonitor) Start(ctx context.Context) {
+	slog.Info("starting url monitor", "interval", m.opts.Interval, "jitter", m.opts.Jitter,
+		"workers", m.opts.Workers, "per_host_concurrency", m.opts.PerHostConcurrency, "per_host_rate", m.opts.PerHostRate)
+	m.beat()
+	defer m.heartbeat.Store(0)
+
+	var runs sync.WaitGroup
+	// Exécute une première passe immédiatement.
+	m.launch(ctx, &runs)
+
+	timer := time.NewTimer(m.nextDelay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			runs.Wait()
+			slog.Info("url monitor stopped")
+			return
+		case <-timer.C:
+			m.beat()
+			m.launch(ctx, &runs)
+			timer.Reset(m.nextDelay())
+		}
 	}
 }
 
-// Start lance la surveillance périodique des URLs dans une goroutine.
-func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
-	ticker := time.NewTicker(m.interval)
-	defer ticker.Stop()
+// launch démarre une passe de vérification, sauf si la précédente est encore en cours.
+func (m *UrlMonitor) launch(ctx context.Context, runs *sync.WaitGroup) {
+	if !m.running.CompareAndSwap(false, true) {
+		metrics.MonitorRunsSkippedTotal.Inc()
+		slog.Warn("previous url check still running, skipping this run")
+		return
+	}
+	runs.Add(1)
+	go func() {
+		defer runs.Done()
+		defer m.running.Store(false)
+		m.checkUrls(ctx)
+	}()
+}
 
-	// Exécute une première vérification immédiatement.
-	m.checkUrls()
+// nextDelay renvoie le délai avant la prochaine passe : l'intervalle, modulé aléatoirement de ±Jitter.
+func (m *UrlMonitor) nextDelay() time.Duration {
+	spread := m.opts.Jitter * (2*rand.Float64() - 1)
+	return time.Duration(float64(m.opts.Interval) * (1 + spread))
+}
+
+// Interval renvoie l'intervalle maximal entre deux passes, variation aléatoire comprise.
+func (m *UrlMonitor) Interval() time.Duration {
+	return time.Duration(float64(m.opts.Interval) * (1 + m.opts.Jitter))
+}
 
-	// Boucle principale déclenchée par le ticker.
-	for range ticker.C {
-		m.checkUrls()
+// LastHeartbeat renvoie l'heure du dernier signal d'activité de la boucle de surveillance,
+// ou l'heure zéro si elle n'est pas en cours d'exécution. Le signal est émis à chaque tour et à chaque URL vérifiée.
+func (m *UrlMonitor) LastHeartbeat() time.Time {
+	nanos := m.heartbeat.Load()
+	if nanos == 0 {
+		return time.Time{}
 	}
+	return time.Unix(0, nanos)
 }
 
-// checkUrls vérifie l'état de toutes les URLs longues enregistrées.
-func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+// beat enregistre un signal d'activité.
+func (m *UrlMonitor) beat() {
+	m.heartbeat.Store(time.Now().UnixNano())
+}
+
+// linkCheck associe un lien au résultat de sa vérification.
+type linkCheck struct {
+	link  models.Link
+	check models.LinkCheck
+}
+
+// checkUrls vérifie l'état de toutes les URLs longues enregistrées ; elle s'interrompt si ctx est annulé.
+// Les vérifications sont menées en parallèle ; leurs résultats sont enregistrés un par un.
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
+	start := time.Now()
+	slog.Debug("checking url states")
 
 	// Récupère toutes les URLs longues actives.
-	links, err := m.linkRepo.GetAllLinks()
+	links, err := m.linkRepo.GetAllLinks(ctx)
 	if err != nil {
-		log.Printf("[MONITOR] erruer lors de la récup des liens pour la surveillance : %v", err)
+		slog.Error("failed to load links to monitor", logging.Err(err))
 		return
 	}
 
-	for _, link := range links {
-		// Vérifie l'accessibilité du lien.
-		currentState := m.isUrlAccessible(link.LongURL)
+	limiter := newHostLimiter(m.opts.PerHostConcurrency, m.opts.PerHostRate)
+	jobs := make(chan models.Link)
+	results := make(chan linkCheck)
+	var workers sync.WaitGroup
+	for i := 0; i < min(m.opts.Workers, len(links)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for link := range jobs {
+				if check, ok := m.checkLink(ctx, limiter, link); ok {
+					results <- linkCheck{link: link, check: check}
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, link := range interleaveByHost(links) {
+			select {
+			case jobs <- link:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
 
-		// Protège l'accès à la map knownStates.
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID]
-		m.knownStates[link.ID] = currentState
-		m.mu.Unlock()
+	for result := range results {
+		m.beat()
+		m.record(ctx, result.link, result.check)
+	}
+	if ctx.Err() != nil {
+		slog.Info("url check interrupted by monitor shutdown")
+		return
+	}
 
-		// Initialise l'état sans notifier si c'est la première vérification.
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
-			continue
-		}
+	m.pruneChecks(ctx)
+	duration := time.Since(start)
+	metrics.MonitorRunDuration.Observe(duration.Seconds())
+	slog.Debug("url state check finished", "links", len(links), "duration", duration)
+}
 
-		// Notifie si l'état a changé.
-		if currentState != previousState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode, link.LongURL, formatState(previousState), formatState(currentState))
-		}
+// checkLink vérifie un lien dans le respect des limites de son hôte.
+// Elle renvoie false si la vérification a été interrompue par l'annulation de ctx.
+func (m *UrlMonitor) checkLink(ctx context.Context, limiter *hostLimiter, link models.Link) (models.LinkCheck, bool) {
+	release, err := limiter.acquire(ctx, linkHost(link))
+	if err != nil {
+		return models.LinkCheck{}, false
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
+	check := m.checkUrl(ctx, link)
+	release()
+	return check, ctx.Err() == nil
 }
 
-// isUrlAccessible vérifie l'accessibilité d'une URL via une requête HTTP HEAD.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
+// record enregistre le résultat d'une vérification, journalise et notifie les changements d'état.
+// Un lien n'est déclaré inaccessible qu'après FailureThreshold échecs consécutifs ; d'ici là, il conserve son état.
+func (m *UrlMonitor) record(ctx context.Context, link models.Link, check models.LinkCheck) {
+	metrics.MonitorChecksTotal.WithLabelValues(stateLabel(check.Accessible)).Inc()
+
+	// L'état précédent est celui enregistré sur le lien, y compris avant un redémarrage.
+	previous := link.HealthStatus
+	if previous == "" {
+		previous = models.LinkHealthUnknown
+	}
+	var failures int
+	if !check.Accessible {
+		failures = link.ConsecutiveFailures + 1
+	}
+	switch {
+	case check.Accessible:
+		check.LinkStatus = models.LinkHealthUp
+	case failures >= m.opts.FailureThreshold:
+		check.LinkStatus = models.LinkHealthDown
+	default:
+		check.LinkStatus = previous
 	}
 
-	// Effectue une requête HEAD.
-	resp, err := client.Head(url)
+	// Le lien a pu changer d'URL longue pendant la vérification : le résultat est alors écarté.
+	err := m.checkRepo.RecordCheck(ctx, &check, link.LongURL, failures)
+	if errors.Is(err, repository.ErrLinkDestinationChanged) {
+		slog.Debug("discarding check of a link changed during the check", logging.KeyLinkID, link.ID, "url", link.LongURL)
+		return
+	}
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-		return false
+		slog.Error("failed to record link check", logging.KeyLinkID, link.ID, logging.Err(err))
 	}
-	defer resp.Body.Close()
 
-	// Codes 2xx ou 3xx indiquent une URL accessible.
-	return resp.StatusCode >= 200 && resp.StatusCode < 400
+	current := check.LinkStatus
+	switch {
+	case previous == current:
+		if failures > 0 && current != models.LinkHealthDown {
+			slog.Info("link check failed, link not declared down yet",
+				logging.KeyLinkID, link.ID, logging.KeyShortCode, link.ShortCode, "url", link.LongURL,
+				"failures", failures, "threshold", m.opts.FailureThreshold, "error_class", check.ErrorClass)
+		}
+	case previous == models.LinkHealthUnknown:
+		slog.Info("initial link state",
+			logging.KeyLinkID, link.ID, logging.KeyShortCode, link.ShortCode, "url", link.LongURL, "state", current)
+	default:
+		slog.Warn("link state changed",
+			logging.KeyLinkID, link.ID, logging.KeyShortCode, link.ShortCode, "url", link.LongURL,
+			"from", previous, "to", current, "status_code", check.StatusCode, "error_class", check.ErrorClass)
+		m.notify(ctx, link, check, previous, current)
+	}
+}
+
+// notify transmet un changement d'état au Notifier configuré.
+func (m *UrlMonitor) notify(ctx context.Context, link models.Link, check models.LinkCheck, from, to string) {
+	if m.opts.Notifier == nil {
+		return
+	}
+	event := notify.Event{
+		LinkID:     link.ID,
+		ShortCode:  link.ShortCode,
+		LongURL:    link.LongURL,
+		From:       from,
+		To:         to,
+		StatusCode: check.StatusCode,
+		ErrorClass: check.ErrorClass,
+		At:         check.CheckedAt,
+	}
+	if err := m.opts.Notifier.Notify(ctx, event); err != nil {
+		slog.Error("failed to notify link state change", logging.KeyLinkID, link.ID, logging.Err(err))
+	}
+}
+
+// pruneChecks supprime les vérifications plus anciennes que la durée de conservation.
+func (m *UrlMonitor) pruneChecks(ctx context.Context) {
+	if m.opts.CheckRetention <= 0 {
+		return
+	}
+	deleted, err := m.checkRepo.DeleteChecksBefore(ctx, time.Now().Add(-m.opts.CheckRetention))
+	if err != nil {
+		slog.Error("failed to prune link checks", logging.Err(err))
+		return
+	}
+	if deleted > 0 {
+		slog.Debug("pruned link checks", "deleted", deleted)
+	}
 }
 
-// formatState rend l'état plus lisible dans les logs.
-func formatState(accessible bool) string {
+// stateLabel renvoie l'état sous forme de libellé de métrique.
+func stateLabel(accessible bool) string {
 	if accessible {
-		return "ACCESSIBLE"
+		return "accessible"
 	}
-	return "INACCESSIBLE"
+	return "inaccessible"
 }
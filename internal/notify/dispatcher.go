@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
+)
+
+// dispatchQueueSize est le nombre de notifications en attente d'envoi au-delà duquel les suivantes sont abandonnées.
+const dispatchQueueSize = 100
+
+// Dispatcher envoie les notifications en arrière-plan, sans ralentir le moniteur, et limite leur fréquence par lien :
+// après une notification, les changements d'état d'un lien sont retenus pendant le délai de cooldown, puis résumés
+// en une seule notification à son expiration si l'état final diffère du dernier état notifié.
+// Un lien instable produit ainsi au plus une notification par cooldown.
+type Dispatcher struct {
+	notifier Notifier
+	cooldown time.Duration
+	queue    chan Event
+	ctx      context.Context // Contexte des envois, annulé par Close.
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu     sync.Mutex
+	links  map[uint]*linkState // Liens en cooldown.
+	closed bool
+}
+
+// linkState est l'état de l'anti-rebond d'un lien en cooldown.
+type linkState struct {
+	notified   string      // Dernier état notifié.
+	pending    *Event      // Dernier changement d'état retenu pendant le cooldown.
+	suppressed int         // Nombre de changements d'état retenus pendant le cooldown.
+	timer      *time.Timer // Fin du cooldown.
+}
+
+// NewDispatcher crée un Dispatcher qui transmet les notifications à notifier, au plus une fois par cooldown et par lien
+// (cooldown à 0 : chaque changement d'état est notifié). Close doit être appelée pour arrêter l'envoi.
+func NewDispatcher(notifier Notifier, cooldown time.Duration) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		notifier: notifier,
+		cooldown: max(cooldown, 0),
+		queue:    make(chan Event, dispatchQueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		links:    make(map[uint]*linkState),
+	}
+	go d.run()
+	return d
+}
+
+// Notify prend en compte un changement d'état. Elle ne bloque pas : l'envoi a lieu en arrière-plan.
+func (d *Dispatcher) Notify(_ context.Context, event Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil
+	}
+
+	state, ok := d.links[event.LinkID]
+	if !ok {
+		d.enqueue(event)
+		d.startCooldown(event.LinkID, event.To)
+		return nil
+	}
+	// Lien en cooldown : le changement est retenu jusqu'à son expiration.
+	pending := event
+	state.pending = &pending
+	state.suppressed++
+	metrics.NotificationsTotal.WithLabelValues("suppressed").Inc()
+	return nil
+}
+
+// startCooldown ouvre le cooldown d'un lien qui vient d'être notifié dans l'état notified.
+// L'appelant doit détenir d.mu.
+func (d *Dispatcher) startCooldown(linkID uint, notified string) {
+	if d.cooldown == 0 {
+		return
+	}
+	d.links[linkID] = &linkState{
+		notified: notified,
+		timer:    time.AfterFunc(d.cooldown, func() { d.endCooldown(linkID) }),
+	}
+}
+
+// endCooldown clôt le cooldown d'un lien et notifie, le cas échéant, le résumé des changements retenus.
+func (d *Dispatcher) endCooldown(linkID uint) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state, ok := d.links[linkID]
+	if !ok || d.closed {
+		return
+	}
+	delete(d.links, linkID)
+
+	// Un lien revenu à l'état déjà notifié n'est pas signalé de nouveau.
+	if state.pending == nil || state.pending.To == state.notified {
+		return
+	}
+	event := *state.pending
+	event.From = state.notified
+	event.Suppressed = state.suppressed
+	d.enqueue(event)
+	d.startCooldown(linkID, event.To)
+}
+
+// enqueue place une notification dans la file d'envoi, ou l'abandonne si la file est pleine.
+// L'appelant doit détenir d.mu.
+func (d *Dispatcher) enqueue(event Event) {
+	select {
+	case d.queue <- event:
+	default:
+		metrics.NotificationsTotal.WithLabelValues("dropped").Inc()
+		slog.Warn("notification queue full, dropping notification",
+			logging.KeyLinkID, event.LinkID, logging.KeyShortCode, event.ShortCode, "to", event.To)
+	}
+}
+
+// run envoie les notifications de la file jusqu'à sa fermeture.
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for event := range d.queue {
+		if err := d.notifier.Notify(d.ctx, event); err != nil {
+			metrics.NotificationsTotal.WithLabelValues("failed").Inc()
+			slog.Error("failed to send notification",
+				logging.KeyLinkID, event.LinkID, logging.KeyShortCode, event.ShortCode, "to", event.To, logging.Err(err))
+			continue
+		}
+		metrics.NotificationsTotal.WithLabelValues("sent").Inc()
+		slog.Info("notification sent",
+			logging.KeyLinkID, event.LinkID, logging.KeyShortCode, event.ShortCode, "from", event.From, "to", event.To)
+	}
+}
+
+// Close arrête le Dispatcher : les changements retenus en cooldown sont abandonnés et les notifications
+// déjà en file sont envoyées. Si ctx est annulé avant la fin des envois, ceux en cours sont interrompus
+// et Close rend la main sans attendre : les notifications restantes échouent en arrière-plan.
+func (d *Dispatcher) Close(ctx context.Context) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	for _, state := range d.links {
+		state.timer.Stop()
+	}
+	close(d.queue)
+	d.mu.Unlock()
+
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		slog.Warn("notification dispatcher did not finish in time, abandoning queued notifications", "queued", len(d.queue))
+	}
+	d.cancel()
+}
@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// recorder est un Notifier qui transmet les événements reçus à un channel.
+type recorder chan Event
+
+func (r recorder) Notify(_ context.Context, event Event) error {
+	r <- event
+	return nil
+}
+
+// next attend le prochain événement envoyé.
+func (r recorder) next(t *testing.T) Event {
+	t.Helper()
+	select {
+	case event := <-r:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification sent")
+		return Event{}
+	}
+}
+
+// none vérifie qu'aucun événement n'est envoyé pendant wait.
+func (r recorder) none(t *testing.T, wait time.Duration) {
+	t.Helper()
+	select {
+	case event := <-r:
+		t.Fatalf("unexpected notification %+v", event)
+	case <-time.After(wait):
+	}
+}
+
+func newTestDispatcher(t *testing.T, cooldown time.Duration) (*Dispatcher, recorder) {
+	t.Helper()
+	sent := make(recorder, 10)
+	d := NewDispatcher(sent, cooldown)
+	t.Cleanup(func() { d.Close(context.Background()) })
+	return d, sent
+}
+
+func TestDispatcherSummarizesChangesDuringCooldown(t *testing.T) {
+	d, sent := newTestDispatcher(t, 100*time.Millisecond)
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	if event := sent.next(t); event.To != "down" || event.Suppressed != 0 {
+		t.Fatalf("first notification = %+v, want an immediate up -> down", event)
+	}
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "down", To: "up"})
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	d.Notify(context.Background(), Event{LinkID: 1, From: "down", To: "up"})
+	sent.none(t, 50*time.Millisecond)
+
+	event := sent.next(t)
+	if event.From != "down" || event.To != "up" || event.Suppressed != 3 {
+		t.Errorf("summary = %+v, want down -> up with 3 suppressed changes", event)
+	}
+}
+
+func TestDispatcherSkipsSummaryWhenStateIsUnchanged(t *testing.T) {
+	d, sent := newTestDispatcher(t, 50*time.Millisecond)
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	sent.next(t)
+	d.Notify(context.Background(), Event{LinkID: 1, From: "down", To: "up"})
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+
+	sent.none(t, 200*time.Millisecond)
+}
+
+func TestDispatcherCooldownIsPerLink(t *testing.T) {
+	d, sent := newTestDispatcher(t, time.Hour)
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	d.Notify(context.Background(), Event{LinkID: 2, From: "up", To: "down"})
+
+	got := map[uint]bool{sent.next(t).LinkID: true, sent.next(t).LinkID: true}
+	if !got[1] || !got[2] {
+		t.Errorf("notified links %v, want 1 and 2", got)
+	}
+}
+
+func TestDispatcherWithoutCooldownSendsEveryChange(t *testing.T) {
+	d, sent := newTestDispatcher(t, 0)
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	d.Notify(context.Background(), Event{LinkID: 1, From: "down", To: "up"})
+
+	if first, second := sent.next(t), sent.next(t); first.To != "down" || second.To != "up" {
+		t.Errorf("notifications = %+v, %+v, want up -> down then down -> up", first, second)
+	}
+}
+
+func TestDispatcherCloseDropsPendingSummaries(t *testing.T) {
+	sent := make(recorder, 10)
+	d := NewDispatcher(sent, 50*time.Millisecond)
+
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+	sent.next(t)
+	d.Notify(context.Background(), Event{LinkID: 1, From: "down", To: "up"})
+	d.Close(context.Background())
+
+	sent.none(t, 150*time.Millisecond)
+}
+
+// blocker est un Notifier dont les envois ne se terminent jamais, même après l'annulation de leur contexte.
+type blocker chan struct{}
+
+func (b blocker) Notify(context.Context, Event) error {
+	<-b
+	return nil
+}
+
+func TestDispatcherCloseGivesUpAfterDeadline(t *testing.T) {
+	stuck := make(blocker)
+	defer close(stuck)
+	d := NewDispatcher(stuck, 0)
+	d.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan struct{})
+	go func() {
+		d.Close(ctx)
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return after its context expired")
+	}
+}
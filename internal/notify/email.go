@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Valeurs par défaut des canaux email.
+const (
+	defaultSMTPPort    = 587              // Port de soumission.
+	defaultSMTPTimeout = 10 * time.Second // Durée maximale d'une session SMTP.
+)
+
+// EmailOptions paramètre un EmailNotifier.
+type EmailOptions struct {
+	Host string
+	Port int
+	// Username et Password activent l'authentification PLAIN ; vides pour un relais sans authentification.
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Timeout borne la session SMTP complète, connexion comprise.
+	Timeout time.Duration
+}
+
+// EmailNotifier envoie chaque événement par email via un serveur SMTP.
+// STARTTLS est utilisé dès que le serveur le propose.
+type EmailNotifier struct {
+	opts EmailOptions
+	addr string
+	auth smtp.Auth
+}
+
+// NewEmailNotifier crée un canal email.
+func NewEmailNotifier(opts EmailOptions) (*EmailNotifier, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("email: smtp_host is required")
+	}
+	if opts.From == "" || len(opts.To) == 0 {
+		return nil, fmt.Errorf("email: from and to are required")
+	}
+	if opts.Port <= 0 {
+		opts.Port = defaultSMTPPort
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultSMTPTimeout
+	}
+	n := &EmailNotifier{
+		opts: opts,
+		addr: net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+	}
+	if opts.Username != "" {
+		n.auth = smtp.PlainAuth("", opts.Username, opts.Password, opts.Host)
+	}
+	return n, nil
+}
+
+// Notify envoie l'événement par email. La session SMTP est bornée par le délai du canal
+// et interrompue si ctx est annulé.
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, n.opts.Timeout)
+	defer cancel()
+	if err := n.send(ctx, n.message(event)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// send transmet msg au serveur SMTP, comme smtp.SendMail, sur une connexion dont l'échéance suit ctx.
+func (n *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	dialer := net.Dialer{Timeout: n.opts.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// L'annulation de ctx débloque immédiatement les lectures et écritures en cours.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.opts.Host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.opts.From); err != nil {
+		return err
+	}
+	for _, to := range n.opts.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message compose l'email de l'événement (en-têtes et corps texte).
+func (n *EmailNotifier) message(event Event) []byte {
+	subject := fmt.Sprintf("[urlshortener] Link %s is %s", event.ShortCode, strings.ToUpper(event.To))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.opts.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", event.Summary())
+	fmt.Fprintf(&b, "Short code: %s\r\n", event.ShortCode)
+	fmt.Fprintf(&b, "URL: %s\r\n", event.LongURL)
+	fmt.Fprintf(&b, "Changed at: %s\r\n", event.At.UTC().Format(time.RFC3339))
+	return []byte(b.String())
+}
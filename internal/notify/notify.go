@@ -0,0 +1,117 @@
+// Package notify envoie les changements d'état des liens constatés par le moniteur d'URLs
+// vers des canaux externes (webhook générique signé, webhook entrant Slack, email SMTP).
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Types de canaux reconnus dans la configuration.
+const (
+	TypeWebhook = "webhook"
+	TypeSlack   = "slack"
+	TypeEmail   = "email"
+)
+
+// Event décrit un changement d'état de santé d'un lien.
+type Event struct {
+	LinkID     uint      `json:"link_id"`
+	ShortCode  string    `json:"short_code"`
+	LongURL    string    `json:"long_url"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	StatusCode int       `json:"status_code,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	At         time.Time `json:"at"`
+	// Suppressed est le nombre de changements d'état regroupés dans cette notification par l'anti-rebond.
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Summary renvoie une description d'une ligne de l'événement.
+func (e Event) Summary() string {
+	summary := fmt.Sprintf("Link %s (%s) is now %s (was %s)", e.ShortCode, e.LongURL, e.To, e.From)
+	if e.StatusCode != 0 {
+		summary += fmt.Sprintf(", HTTP %d", e.StatusCode)
+	}
+	if e.ErrorClass != "" {
+		summary += ", error: " + e.ErrorClass
+	}
+	if e.Suppressed > 0 {
+		summary += fmt.Sprintf(" [%d state change(s) during cooldown]", e.Suppressed)
+	}
+	return summary
+}
+
+// Notifier transmet un changement d'état.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi transmet chaque événement à plusieurs canaux. L'échec de l'un n'empêche pas l'envoi aux autres.
+type Multi []Notifier
+
+// Notify envoie l'événement à tous les canaux en parallèle, pour qu'un canal lent ne retarde pas les autres,
+// et renvoie l'ensemble des erreurs rencontrées.
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	errs := make([]error, len(m))
+	var wg sync.WaitGroup
+	for i, notifier := range m {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = notifier.Notify(ctx, event)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// FromConfig construit les canaux décrits dans la configuration, combinés dans un Multi.
+func FromConfig(cfgs []config.NotifierConfig) (Multi, error) {
+	notifiers := make(Multi, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		notifier, err := newNotifier(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel #%d (%s): %w", i+1, cfg.Type, err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
+
+// newNotifier crée un canal selon son type.
+func newNotifier(cfg config.NotifierConfig) (Notifier, error) {
+	httpOpts := HTTPOptions{
+		Timeout:    time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		MaxRetries: defaultMaxRetries,
+		Headers:    cfg.Headers,
+	}
+	// Une clé max_retries absente garde la valeur par défaut ; 0 désactive les nouvelles tentatives.
+	if cfg.MaxRetries != nil {
+		httpOpts.MaxRetries = *cfg.MaxRetries
+	}
+	switch cfg.Type {
+	case TypeWebhook:
+		return NewWebhookNotifier(cfg.URL, cfg.Secret, httpOpts)
+	case TypeSlack:
+		return NewSlackNotifier(cfg.URL, httpOpts)
+	case TypeEmail:
+		return NewEmailNotifier(EmailOptions{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+			To:       cfg.To,
+			Timeout:  time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		})
+	default:
+		return nil, fmt.Errorf("unknown notification channel type %q", cfg.Type)
+	}
+}
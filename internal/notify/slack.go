@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// SlackNotifier envoie chaque événement à un webhook entrant au format Slack ({"text": ...}),
+// également accepté par les outils compatibles (Mattermost, Rocket.Chat...).
+type SlackNotifier struct {
+	poster *httpPoster
+}
+
+// NewSlackNotifier crée un canal Slack vers l'URL du webhook entrant.
+func NewSlackNotifier(url string, opts HTTPOptions) (*SlackNotifier, error) {
+	poster, err := newHTTPPoster(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &SlackNotifier{poster: poster}, nil
+}
+
+// Notify envoie l'événement au webhook Slack.
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(map[string]string{"text": slackText(event)})
+	if err != nil {
+		return fmt.Errorf("slack: failed to encode message: %w", err)
+	}
+	if err := n.poster.post(ctx, body, nil); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// slackText met en forme l'événement en mrkdwn Slack.
+func slackText(event Event) string {
+	icon := ":red_circle:"
+	if event.To == models.LinkHealthUp {
+		icon = ":large_green_circle:"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s Link *%s* is now *%s* (was %s)\n<%s>", icon, event.ShortCode, strings.ToUpper(event.To), event.From, event.LongURL)
+	if event.StatusCode != 0 {
+		fmt.Fprintf(&b, "\nHTTP status: %d", event.StatusCode)
+	}
+	if event.ErrorClass != "" {
+		fmt.Fprintf(&b, "\nError: %s", event.ErrorClass)
+	}
+	if event.Suppressed > 0 {
+		fmt.Fprintf(&b, "\n_%d state change(s) during cooldown_", event.Suppressed)
+	}
+	return b.String()
+}
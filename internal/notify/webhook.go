@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Valeurs par défaut des canaux HTTP.
+const (
+	defaultHTTPTimeout = 10 * time.Second
+	defaultMaxRetries  = 3
+	retryBaseDelay     = 500 * time.Millisecond
+)
+
+// En-têtes ajoutés aux requêtes du webhook générique.
+const (
+	// SignatureHeader porte la signature HMAC-SHA256 du corps, au format "sha256=<hex>".
+	SignatureHeader = "X-Signature-256"
+	// TimestampHeader porte l'heure d'envoi (secondes Unix), incluse dans la signature pour empêcher le rejeu.
+	TimestampHeader = "X-Signature-Timestamp"
+)
+
+// HTTPOptions paramètre les canaux de notification HTTP.
+type HTTPOptions struct {
+	// Timeout borne chaque requête HTTP.
+	Timeout time.Duration
+	// MaxRetries est le nombre de nouvelles tentatives après un échec (0 : aucune).
+	MaxRetries int
+	// Headers sont ajoutés à chaque requête (ex: Authorization).
+	Headers map[string]string
+}
+
+// httpPoster envoie des corps JSON avec de nouvelles tentatives à délai croissant.
+type httpPoster struct {
+	url    string
+	opts   HTTPOptions
+	client *http.Client
+}
+
+// newHTTPPoster crée un httpPoster vers url en appliquant les valeurs par défaut des options.
+func newHTTPPoster(url string, opts HTTPOptions) (*httpPoster, error) {
+	if url == "" {
+		return nil, fmt.Errorf("a url is required")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultHTTPTimeout
+	}
+	return &httpPoster{url: url, opts: opts, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+// post envoie body, en réessayant les erreurs réseau, les réponses 429 et 5xx jusqu'à MaxRetries fois.
+// sign, s'il est fourni, ajoute des en-têtes propres à chaque tentative.
+func (p *httpPoster) post(ctx context.Context, body []byte, sign func(req *http.Request)) error {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		retryable, err := p.postOnce(ctx, body, sign)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= p.opts.MaxRetries {
+			return fmt.Errorf("failed after %d attempt(s): %w", attempt+1, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// postOnce effectue une requête et indique si un échec mérite une nouvelle tentative.
+func (p *httpPoster) postOnce(ctx context.Context, body []byte, sign func(req *http.Request)) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	if sign != nil {
+		sign(req)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// WebhookNotifier envoie chaque événement en JSON dans une requête POST.
+// Si un secret est configuré, la requête est signée : X-Signature-256 vaut "sha256=" suivi du HMAC-SHA256
+// hexadécimal de "<X-Signature-Timestamp>.<corps>".
+type WebhookNotifier struct {
+	poster *httpPoster
+	secret []byte
+}
+
+// NewWebhookNotifier crée un canal webhook vers url, signé avec secret s'il n'est pas vide.
+func NewWebhookNotifier(url, secret string, opts HTTPOptions) (*WebhookNotifier, error) {
+	poster, err := newHTTPPoster(url, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookNotifier{poster: poster, secret: []byte(secret)}, nil
+}
+
+// Notify envoie l'événement au webhook.
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to encode event: %w", err)
+	}
+	if err := n.poster.post(ctx, body, n.sign(body)); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// sign renvoie la fonction de signature des requêtes, ou nil sans secret.
+func (n *WebhookNotifier) sign(body []byte) func(req *http.Request) {
+	if len(n.secret) == 0 {
+		return nil
+	}
+	return func(req *http.Request) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Signature(n.secret, timestamp, body))
+	}
+}
+
+// Signature calcule la signature HMAC-SHA256 hexadécimale d'un corps envoyé à l'heure timestamp.
+// Le destinataire la recalcule pour authentifier la requête.
+func Signature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
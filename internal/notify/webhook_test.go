@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+func TestWebhookMaxRetries(t *testing.T) {
+	retries := func(n int) *int { return &n }
+	tests := []struct {
+		name       string
+		maxRetries *int
+		attempts   int32
+	}{
+		{"zero disables retries", retries(0), 1},
+		{"one retry", retries(1), 2},
+		{"unset uses the default", nil, defaultMaxRetries + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			notifier, err := newNotifier(config.NotifierConfig{Type: TypeWebhook, URL: server.URL, MaxRetries: tt.maxRetries})
+			if err != nil {
+				t.Fatalf("newNotifier: %v", err)
+			}
+			if err := notifier.Notify(context.Background(), Event{LinkID: 1, From: "up", To: "down"}); err == nil {
+				t.Fatal("Notify succeeded against a failing server")
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("%d attempt(s), want %d", got, tt.attempts)
+			}
+		})
+	}
+}
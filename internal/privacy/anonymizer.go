@@ -0,0 +1,105 @@
+// Package privacy regroupe les traitements de protection des données personnelles des clics :
+// anonymisation des adresses IP à l'ingestion et correspondance des demandes d'effacement.
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// Modes d'anonymisation des adresses IP.
+const (
+	// ModeNone conserve l'adresse IP complète.
+	ModeNone = "none"
+	// ModeTruncate ne conserve que le préfixe /24 (IPv4) ou /48 (IPv6).
+	ModeTruncate = "truncate"
+	// ModeHash remplace l'adresse par un HMAC-SHA256 à clé secrète.
+	ModeHash = "hash"
+)
+
+// Longueurs de préfixe conservées par la troncature.
+const (
+	ipv4PrefixBits = 24
+	ipv6PrefixBits = 48
+)
+
+// hashHexLength est le nombre de caractères hexadécimaux conservés d'une adresse hachée (128 bits).
+const hashHexLength = 32
+
+// Anonymizer transforme les adresses IP selon le mode configuré.
+type Anonymizer struct {
+	mode string
+	key  []byte
+}
+
+// NewAnonymizer crée un anonymiseur. Le mode hash exige une clé : sans elle, l'espace IPv4
+// se parcourt en quelques minutes et l'empreinte serait réversible.
+func NewAnonymizer(mode, hashKey string) (*Anonymizer, error) {
+	switch mode {
+	case "", ModeNone:
+		return &Anonymizer{mode: ModeNone}, nil
+	case ModeTruncate:
+		return &Anonymizer{mode: ModeTruncate}, nil
+	case ModeHash:
+		if hashKey == "" {
+			return nil, fmt.Errorf("ip anonymization mode %q requires a hash key", ModeHash)
+		}
+		return &Anonymizer{mode: ModeHash, key: []byte(hashKey)}, nil
+	default:
+		return nil, fmt.Errorf("unknown ip anonymization mode %q", mode)
+	}
+}
+
+// Mode renvoie le mode d'anonymisation appliqué.
+func (a *Anonymizer) Mode() string {
+	return a.mode
+}
+
+// Anonymize renvoie la forme conservée d'une adresse IP. Une valeur qui n'est pas une adresse IP
+// est vidée, sauf en mode none.
+func (a *Anonymizer) Anonymize(address string) string {
+	if a.mode == ModeNone {
+		return address
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+	if a.mode == ModeHash {
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte(ip.String()))
+		return hex.EncodeToString(mac.Sum(nil))[:hashHexLength]
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(ipv4PrefixBits, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(ipv6PrefixBits, 128)).String()
+}
+
+// AnonymizeStored renvoie la forme conservée d'une valeur déjà enregistrée et indique si elle diffère.
+// Les valeurs qui ne sont pas des adresses IP, comme les empreintes du mode hash, sont laissées telles quelles :
+// appliquer AnonymizeStored à son propre résultat ne change rien.
+func (a *Anonymizer) AnonymizeStored(value string) (string, bool) {
+	if net.ParseIP(value) == nil {
+		return value, false
+	}
+	anonymized := a.Anonymize(value)
+	return anonymized, anonymized != value
+}
+
+// StoredForms renvoie toutes les formes sous lesquelles une adresse IP peut avoir été enregistrée :
+// l'adresse complète (clics antérieurs à l'activation de l'anonymisation) et sa forme anonymisée.
+func (a *Anonymizer) StoredForms(address string) []string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil
+	}
+	forms := []string{ip.String()}
+	if anonymized := a.Anonymize(ip.String()); anonymized != "" && anonymized != forms[0] {
+		forms = append(forms, anonymized)
+	}
+	return forms
+}
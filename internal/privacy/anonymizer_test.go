@@ -0,0 +1,125 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAnonymizerValidatesMode(t *testing.T) {
+	tests := []struct {
+		mode, key string
+		wantMode  string
+		wantErr   bool
+	}{
+		{"", "", ModeNone, false},
+		{ModeNone, "", ModeNone, false},
+		{ModeTruncate, "", ModeTruncate, false},
+		{ModeHash, "secret", ModeHash, false},
+		{ModeHash, "", "", true},
+		{"mask", "", "", true},
+	}
+	for _, tt := range tests {
+		a, err := NewAnonymizer(tt.mode, tt.key)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewAnonymizer(%q, %q) succeeded, want an error", tt.mode, tt.key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewAnonymizer(%q, %q): %v", tt.mode, tt.key, err)
+			continue
+		}
+		if a.Mode() != tt.wantMode {
+			t.Errorf("NewAnonymizer(%q).Mode() = %q, want %q", tt.mode, a.Mode(), tt.wantMode)
+		}
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	tests := []struct {
+		mode, address, want string
+	}{
+		{ModeNone, "203.0.113.42", "203.0.113.42"},
+		{ModeNone, "not an ip", "not an ip"},
+		{ModeTruncate, "203.0.113.42", "203.0.113.0"},
+		{ModeTruncate, "203.0.113.0", "203.0.113.0"},
+		{ModeTruncate, "::ffff:203.0.113.42", "203.0.113.0"},
+		{ModeTruncate, "2001:db8:1234:5678::1", "2001:db8:1234::"},
+		{ModeTruncate, "not an ip", ""},
+		{ModeTruncate, "", ""},
+		{ModeHash, "not an ip", ""},
+	}
+	for _, tt := range tests {
+		a, err := NewAnonymizer(tt.mode, "secret")
+		if err != nil {
+			t.Fatalf("NewAnonymizer(%q): %v", tt.mode, err)
+		}
+		if got := a.Anonymize(tt.address); got != tt.want {
+			t.Errorf("%s: Anonymize(%q) = %q, want %q", tt.mode, tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizeHash(t *testing.T) {
+	a, _ := NewAnonymizer(ModeHash, "secret")
+	other, _ := NewAnonymizer(ModeHash, "other secret")
+
+	hashed := a.Anonymize("203.0.113.42")
+	if len(hashed) != hashHexLength || strings.Trim(hashed, "0123456789abcdef") != "" {
+		t.Fatalf("Anonymize = %q, want %d hexadecimal characters", hashed, hashHexLength)
+	}
+	if again := a.Anonymize("203.0.113.42"); again != hashed {
+		t.Errorf("Anonymize is not deterministic: %q then %q", hashed, again)
+	}
+	// Une même adresse écrite autrement a la même empreinte.
+	if mapped := a.Anonymize("::ffff:203.0.113.42"); mapped != hashed {
+		t.Errorf("Anonymize(::ffff:203.0.113.42) = %q, want %q", mapped, hashed)
+	}
+	if a.Anonymize("203.0.113.43") == hashed {
+		t.Error("two addresses share the same hash")
+	}
+	if other.Anonymize("203.0.113.42") == hashed {
+		t.Error("the hash does not depend on the key")
+	}
+}
+
+func TestAnonymizeStoredIsIdempotent(t *testing.T) {
+	for _, mode := range []string{ModeNone, ModeTruncate, ModeHash} {
+		a, _ := NewAnonymizer(mode, "secret")
+		for _, stored := range []string{"203.0.113.42", "2001:db8::1", "203.0.113.0", ""} {
+			first, _ := a.AnonymizeStored(stored)
+			second, changed := a.AnonymizeStored(first)
+			if changed || second != first {
+				t.Errorf("%s: AnonymizeStored(%q) = %q, then %q (changed %t)", mode, stored, first, second, changed)
+			}
+		}
+	}
+
+	a, _ := NewAnonymizer(ModeTruncate, "")
+	if got, changed := a.AnonymizeStored("203.0.113.42"); !changed || got != "203.0.113.0" {
+		t.Errorf("AnonymizeStored(203.0.113.42) = %q, %t, want 203.0.113.0, true", got, changed)
+	}
+}
+
+func TestStoredForms(t *testing.T) {
+	truncate, _ := NewAnonymizer(ModeTruncate, "")
+	none, _ := NewAnonymizer(ModeNone, "")
+	tests := []struct {
+		name    string
+		a       *Anonymizer
+		address string
+		want    []string
+	}{
+		{"truncate", truncate, "203.0.113.42", []string{"203.0.113.42", "203.0.113.0"}},
+		{"already truncated", truncate, "203.0.113.0", []string{"203.0.113.0"}},
+		{"none", none, "203.0.113.42", []string{"203.0.113.42"}},
+		{"invalid", truncate, "nope", nil},
+	}
+	for _, tt := range tests {
+		got := tt.a.StoredForms(tt.address)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: StoredForms(%q) = %v, want %v", tt.name, tt.address, got, tt.want)
+		}
+	}
+}
@@ -1,14 +1,56 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
 
+// Dimensions disponibles pour la ventilation des clics.
+const (
+	DimensionBrowser = "browser"
+	DimensionOS      = "os"
+	DimensionDevice  = "device"
+	DimensionBot     = "bot"
+
+	DimensionReferrer       = "referrer"
+	DimensionReferrerDomain = "referrer_domain"
+
+	DimensionCountry = "country"
+	DimensionRegion  = "region"
+	DimensionCity    = "city"
+)
+
+// dimensionExpressions associe chaque dimension à l'expression SQL de regroupement.
+// Seules ces expressions peuvent être injectées dans les requêtes.
+var dimensionExpressions = map[string]string{
+	DimensionBrowser: "browser",
+	DimensionOS:      "os",
+	DimensionDevice:  "device_type",
+	DimensionBot:     "CASE WHEN is_bot THEN 'bot' ELSE 'human' END",
+
+	DimensionReferrer:       "referrer",
+	DimensionReferrerDomain: "referrer_host",
+
+	DimensionCountry: "country",
+	DimensionRegion:  "region",
+	DimensionCity:    "city",
+}
+
 // ClickRepository définit les méthodes d'accès aux données pour les clics.
 type ClickRepository interface {
-	CreateClick(click *models.Click) error
-	CountClicksByLinkID(linkID uint) (int, error)
+	CreateClick(ctx context.Context, click *models.Click) error
+	CreateClicks(ctx context.Context, clicks []models.Click) error
+	CountClicksByLinkID(ctx context.Context, linkID uint) (int, error)
+	CountClicksBySlot(ctx context.Context, linkID uint, from, to time.Time, slot time.Duration) ([]models.ClickSlot, error)
+	CountClicksByDimension(ctx context.Context, linkID uint, dimension string, limit int) ([]models.BreakdownEntry, error)
+	CountUniqueVisitorsByDay(ctx context.Context, linkID uint) ([]models.DailyUniqueVisitors, error)
+	GetRollups(ctx context.Context, linkID uint) ([]models.ClickRollup, error)
+	OldestClickBefore(ctx context.Context, cutoff time.Time) (*time.Time, error)
+	RollupClicks(ctx context.Context, from, to time.Time) (int64, error)
 }
 
 // GormClickRepository implémente ClickRepository avec GORM.
@@ -22,16 +64,219 @@ func NewClickRepository(db *gorm.DB) *GormClickRepository {
 }
 
 // CreateClick insère un nouvel enregistrement de clic.
-func (r *GormClickRepository) CreateClick(click *models.Click) error {
-	return r.db.Create(click).Error
+func (r *GormClickRepository) CreateClick(ctx context.Context, click *models.Click) error {
+	return r.db.WithContext(ctx).Create(click).Error
 }
 
-// CountClicksByLinkID compte le nombre total de clics pour un lien donné.
-func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
+// insertBatchSize borne le nombre de lignes par requête INSERT pour rester sous la limite
+// de paramètres de SQLite.
+const insertBatchSize = 200
+
+// CreateClicks insère plusieurs clics dans une seule transaction.
+func (r *GormClickRepository) CreateClicks(ctx context.Context, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(clicks, insertBatchSize).Error
+	})
+}
+
+// CountClicksByLinkID compte le nombre total de clics pour un lien donné, clics agrégés compris.
+func (r *GormClickRepository) CountClicksByLinkID(ctx context.Context, linkID uint) (int, error) {
 	var count int64
-	err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error
+	err := r.db.WithContext(ctx).Raw(`SELECT (SELECT COUNT(*) FROM clicks WHERE link_id = ?)
+		+ (SELECT COALESCE(SUM(clicks), 0) FROM click_rollups WHERE link_id = ?)`, linkID, linkID).
+		Scan(&count).Error
 	if err != nil {
 		return 0, err
 	}
 	return int(count), nil
 }
+
+// CountClicksBySlot compte les clics bruts d'un lien dans l'intervalle [from, to) par créneau de durée slot,
+// aligné sur l'époque Unix, du plus ancien au plus récent. Seuls les créneaux contenant des clics sont renvoyés.
+// Les clics déjà agrégés sont fournis par GetRollups.
+func (r *GormClickRepository) CountClicksBySlot(ctx context.Context, linkID uint, from, to time.Time, slot time.Duration) ([]models.ClickSlot, error) {
+	seconds := int64(slot / time.Second)
+	if seconds <= 0 {
+		return nil, fmt.Errorf("invalid click slot %s", slot)
+	}
+	// strftime('%s') convertit l'horodatage enregistré, avec son décalage horaire, en secondes Unix.
+	var rows []struct {
+		Slot   int64
+		Clicks int
+	}
+	err := r.db.WithContext(ctx).Model(&models.Click{}).
+		Select("CAST(strftime('%s', timestamp) AS INTEGER) / ? * ? AS slot, COUNT(*) AS clicks", seconds, seconds).
+		Where("link_id = ? AND timestamp >= ? AND timestamp < ?", linkID, dbTime(from), dbTime(to)).
+		Group("slot").
+		Order("slot").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]models.ClickSlot, len(rows))
+	for i, row := range rows {
+		slots[i] = models.ClickSlot{Start: time.Unix(row.Slot, 0), Clicks: row.Clicks}
+	}
+	return slots, nil
+}
+
+// CountClicksByDimension ventile les clics d'un lien selon une dimension, par nombre de clics décroissant.
+// Les clics bruts et les clics agrégés sont additionnés.
+func (r *GormClickRepository) CountClicksByDimension(ctx context.Context, linkID uint, dimension string, limit int) ([]models.BreakdownEntry, error) {
+	expr, ok := dimensionExpressions[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown click dimension %q", dimension)
+	}
+	raw := r.db.WithContext(ctx).Model(&models.Click{}).
+		Select(fmt.Sprintf("COALESCE(%s, '') AS value, COUNT(*) AS clicks", expr)).
+		Where("link_id = ?", linkID).
+		Group("value")
+	rolled := r.db.WithContext(ctx).Model(&models.ClickDimensionRollup{}).
+		Select("value, SUM(clicks) AS clicks").
+		Where("link_id = ? AND dimension = ?", linkID, dimension).
+		Group("value")
+
+	var entries []models.BreakdownEntry
+	err := r.db.WithContext(ctx).Table("(? UNION ALL ?) AS c", raw, rolled).
+		Select("value, SUM(clicks) AS clicks").
+		Group("value").
+		Order("clicks DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
+
+// CountUniqueVisitorsByDay compte les empreintes de visiteurs distinctes d'un lien pour chaque jour (UTC).
+// Les sels changeant chaque jour, les empreintes ne sont comparables qu'au sein d'une même journée.
+// Les jours agrégés reprennent le nombre calculé lors de l'agrégation.
+func (r *GormClickRepository) CountUniqueVisitorsByDay(ctx context.Context, linkID uint) ([]models.DailyUniqueVisitors, error) {
+	raw := r.db.WithContext(ctx).Model(&models.Click{}).
+		Select("date(timestamp) AS day, COUNT(DISTINCT visitor_hash) AS visitors").
+		Where("link_id = ? AND visitor_hash <> ''", linkID).
+		Group("day")
+	rolled := r.db.WithContext(ctx).Model(&models.ClickRollup{}).
+		Select("day, uniques AS visitors").
+		Where("link_id = ? AND uniques > 0", linkID)
+
+	var days []models.DailyUniqueVisitors
+	err := r.db.WithContext(ctx).Table("(? UNION ALL ?) AS v", raw, rolled).
+		Select("day, SUM(visitors) AS visitors").
+		Group("day").
+		Order("day").
+		Scan(&days).Error
+	return days, err
+}
+
+// GetRollups récupère les agrégats quotidiens d'un lien, du jour le plus ancien au plus récent.
+func (r *GormClickRepository) GetRollups(ctx context.Context, linkID uint) ([]models.ClickRollup, error) {
+	var rollups []models.ClickRollup
+	err := r.db.WithContext(ctx).Where("link_id = ?", linkID).Order("day").Find(&rollups).Error
+	return rollups, err
+}
+
+// OldestClickBefore renvoie l'horodatage du plus ancien clic brut antérieur à cutoff, ou nil s'il n'y en a pas.
+func (r *GormClickRepository) OldestClickBefore(ctx context.Context, cutoff time.Time) (*time.Time, error) {
+	var clicks []models.Click
+	err := r.db.WithContext(ctx).Select("timestamp").
+		Where("timestamp < ?", dbTime(cutoff)).
+		Order("timestamp").
+		Limit(1).
+		Find(&clicks).Error
+	if err != nil || len(clicks) == 0 {
+		return nil, err
+	}
+	return &clicks[0].Timestamp, nil
+}
+
+// RollupClicks agrège les clics bruts de l'intervalle [from, to) par lien et par jour (UTC), puis les supprime,
+// dans une seule transaction. Les agrégats existants sont complétés, ce qui couvre les clics arrivés en retard
+// (rejeu du spool) ; leurs visiteurs uniques sont alors additionnés et peuvent être surestimés.
+// Elle renvoie le nombre de clics bruts supprimés.
+func (r *GormClickRepository) RollupClicks(ctx context.Context, from, to time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO click_rollups (link_id, day, clicks, uniques)
+			SELECT link_id, date(timestamp), COUNT(*), COUNT(DISTINCT NULLIF(visitor_hash, ''))
+			FROM clicks WHERE timestamp >= ? AND timestamp < ?
+			GROUP BY link_id, date(timestamp)
+			ON CONFLICT (link_id, day) DO UPDATE SET
+				clicks = clicks + excluded.clicks,
+				uniques = uniques + excluded.uniques`, dbTime(from), dbTime(to)).Error
+		if err != nil {
+			return fmt.Errorf("failed to roll up clicks: %w", err)
+		}
+
+		for dimension, expr := range dimensionExpressions {
+			err := tx.Exec(fmt.Sprintf(`INSERT INTO click_dimension_rollups (link_id, day, dimension, value, clicks)
+				SELECT link_id, date(timestamp), ?, COALESCE(%[1]s, ''), COUNT(*)
+				FROM clicks WHERE timestamp >= ? AND timestamp < ?
+				GROUP BY link_id, date(timestamp), COALESCE(%[1]s, '')
+				ON CONFLICT (link_id, day, dimension, value) DO UPDATE SET
+					clicks = clicks + excluded.clicks`, expr), dimension, dbTime(from), dbTime(to)).Error
+			if err != nil {
+				return fmt.Errorf("failed to roll up clicks by %s: %w", dimension, err)
+			}
+		}
+
+		result := tx.Where("timestamp >= ? AND timestamp < ?", dbTime(from), dbTime(to)).Delete(&models.Click{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge rolled up clicks: %w", result.Error)
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+// rewriteIPBatchSize est le nombre d'adresses IP distinctes traitées par lot par RewriteIPAddresses.
+const rewriteIPBatchSize = 1000
+
+// RewriteIPAddresses remplace l'adresse IP des clics enregistrés par la valeur renvoyée par rewrite,
+// lorsqu'elle indique un changement, et renvoie le nombre de clics modifiés. rewrite doit être idempotente :
+// une valeur réécrite peut lui être présentée à nouveau.
+func (r *GormClickRepository) RewriteIPAddresses(ctx context.Context, rewrite func(string) (string, bool)) (int64, error) {
+	var rewritten int64
+	last := ""
+	for {
+		var addresses []string
+		err := r.db.WithContext(ctx).Model(&models.Click{}).
+			Distinct("ip_address").
+			Where("ip_address > ?", last).
+			Order("ip_address").
+			Limit(rewriteIPBatchSize).
+			Pluck("ip_address", &addresses).Error
+		if err != nil {
+			return rewritten, err
+		}
+		if len(addresses) == 0 {
+			return rewritten, nil
+		}
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, address := range addresses {
+				anonymized, changed := rewrite(address)
+				if !changed {
+					continue
+				}
+				result := tx.Model(&models.Click{}).Where("ip_address = ?", address).Update("ip_address", anonymized)
+				if result.Error != nil {
+					return result.Error
+				}
+				rewritten += result.RowsAffected
+			}
+			return nil
+		})
+		if err != nil {
+			return rewritten, err
+		}
+		last = addresses[len(addresses)-1]
+	}
+}
+
+// dbTime convertit une borne de requête dans le fuseau local, celui des horodatages enregistrés,
+// car SQLite compare les dates sous forme de texte.
+func dbTime(t time.Time) time.Time {
+	return t.In(time.Local)
+}
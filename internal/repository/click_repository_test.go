@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// createClicks enregistre un clic par empreinte de visiteur à l'instant donné, horodaté comme par les workers
+// dans le fuseau local.
+func createClicks(t *testing.T, repo *GormClickRepository, linkID uint, at time.Time, visitorHashes ...string) {
+	t.Helper()
+	clicks := make([]models.Click, len(visitorHashes))
+	for i, visitorHash := range visitorHashes {
+		clicks[i] = models.Click{LinkID: linkID, Timestamp: at.In(time.Local), VisitorHash: visitorHash, Browser: "Firefox"}
+	}
+	if err := repo.CreateClicks(context.Background(), clicks); err != nil {
+		t.Fatalf("CreateClicks: %v", err)
+	}
+}
+
+func TestRollupClicksKeepsTotals(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewClickRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	day1 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+
+	createClicks(t, repo, link.ID, day1, "a", "a", "b", "")
+	createClicks(t, repo, link.ID, day2, "a", "c")
+	createClicks(t, repo, link.ID, day3, "d")
+
+	purged, err := repo.RollupClicks(ctx, day1.Truncate(24*time.Hour), day3.Truncate(24*time.Hour))
+	if err != nil {
+		t.Fatalf("RollupClicks: %v", err)
+	}
+	if purged != 6 {
+		t.Errorf("purged %d click(s), want 6", purged)
+	}
+
+	rollups, err := repo.GetRollups(ctx, link.ID)
+	if err != nil {
+		t.Fatalf("GetRollups: %v", err)
+	}
+	want := []models.ClickRollup{
+		{LinkID: link.ID, Day: "2026-10-01", Clicks: 4, Uniques: 2},
+		{LinkID: link.ID, Day: "2026-10-02", Clicks: 2, Uniques: 2},
+	}
+	if len(rollups) != len(want) {
+		t.Fatalf("rollups = %+v, want %+v", rollups, want)
+	}
+	for i := range want {
+		if rollups[i] != want[i] {
+			t.Errorf("rollup %d = %+v, want %+v", i, rollups[i], want[i])
+		}
+	}
+
+	// Les totaux ne changent pas : les clics purgés sont repris des agrégats.
+	if total, _ := repo.CountClicksByLinkID(ctx, link.ID); total != 7 {
+		t.Errorf("CountClicksByLinkID = %d, want 7", total)
+	}
+	days, err := repo.CountUniqueVisitorsByDay(ctx, link.ID)
+	if err != nil {
+		t.Fatalf("CountUniqueVisitorsByDay: %v", err)
+	}
+	if len(days) != 3 || days[0].Visitors != 2 || days[1].Visitors != 2 || days[2].Visitors != 1 {
+		t.Errorf("unique visitors by day = %+v, want 2, 2 and 1", days)
+	}
+	browsers, err := repo.CountClicksByDimension(ctx, link.ID, DimensionBrowser, 10)
+	if err != nil {
+		t.Fatalf("CountClicksByDimension: %v", err)
+	}
+	if len(browsers) != 1 || browsers[0].Clicks != 7 {
+		t.Errorf("browser breakdown = %+v, want 7 Firefox clicks", browsers)
+	}
+}
+
+func TestRollupClicksCompletesExistingRollups(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewClickRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	from, to := day.Truncate(24*time.Hour), day.Truncate(24*time.Hour).AddDate(0, 0, 1)
+
+	createClicks(t, repo, link.ID, day, "a", "b")
+	if _, err := repo.RollupClicks(ctx, from, to); err != nil {
+		t.Fatalf("RollupClicks: %v", err)
+	}
+	// Un clic arrivé en retard (rejeu du spool) est agrégé au passage suivant.
+	createClicks(t, repo, link.ID, day.Add(time.Hour), "c")
+	if _, err := repo.RollupClicks(ctx, from, to); err != nil {
+		t.Fatalf("second RollupClicks: %v", err)
+	}
+
+	rollups, _ := repo.GetRollups(ctx, link.ID)
+	if len(rollups) != 1 || rollups[0].Clicks != 3 || rollups[0].Uniques != 3 {
+		t.Errorf("rollups = %+v, want one day with 3 clicks and 3 visitors", rollups)
+	}
+	if oldest, err := repo.OldestClickBefore(ctx, to); err != nil || oldest != nil {
+		t.Errorf("OldestClickBefore = %v, %v, want no raw click left", oldest, err)
+	}
+}
+
+func TestRewriteIPAddresses(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewClickRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	for _, address := range []string{"203.0.113.42", "203.0.113.42", "203.0.113.7", "198.51.100.0", ""} {
+		if err := repo.CreateClick(ctx, &models.Click{LinkID: link.ID, Timestamp: time.Now(), IPAddress: address}); err != nil {
+			t.Fatalf("CreateClick: %v", err)
+		}
+	}
+
+	// Tronque les adresses en .0 ; les valeurs déjà tronquées ou vides sont inchangées.
+	truncate := func(address string) (string, bool) {
+		i := strings.LastIndex(address, ".")
+		if i < 0 || address[i+1:] == "0" {
+			return address, false
+		}
+		return address[:i+1] + "0", true
+	}
+	rewritten, err := repo.RewriteIPAddresses(ctx, truncate)
+	if err != nil {
+		t.Fatalf("RewriteIPAddresses: %v", err)
+	}
+	if rewritten != 3 {
+		t.Errorf("rewrote %d click(s), want 3", rewritten)
+	}
+	var addresses []string
+	db.Model(&models.Click{}).Order("ip_address").Pluck("ip_address", &addresses)
+	if want := ",198.51.100.0,203.0.113.0,203.0.113.0,203.0.113.0"; strings.Join(addresses, ",") != want {
+		t.Errorf("addresses = %v, want %s", addresses, want)
+	}
+	if again, _ := repo.RewriteIPAddresses(ctx, truncate); again != 0 {
+		t.Errorf("second pass rewrote %d click(s), want 0", again)
+	}
+}
+
+func TestCountClicksBySlot(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewClickRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	other := createTestLink(t, db, "def456", "https://example.org")
+	from := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	for _, at := range []time.Duration{-time.Second, 0, 14*time.Minute + 59*time.Second, 15 * time.Minute, 44 * time.Minute, time.Hour} {
+		createClicks(t, repo, link.ID, from.Add(at), "a")
+	}
+	createClicks(t, repo, other.ID, from, "b")
+
+	// Les bornes peuvent être exprimées dans n'importe quel fuseau.
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	slots, err := repo.CountClicksBySlot(ctx, link.ID, from.In(kolkata), to.In(kolkata), 15*time.Minute)
+	if err != nil {
+		t.Fatalf("CountClicksBySlot: %v", err)
+	}
+	want := []models.ClickSlot{
+		{Start: from, Clicks: 2},
+		{Start: from.Add(15 * time.Minute), Clicks: 1},
+		{Start: from.Add(30 * time.Minute), Clicks: 1},
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("slots = %+v, want %+v", slots, want)
+	}
+	for i := range want {
+		if !slots[i].Start.Equal(want[i].Start) || slots[i].Clicks != want[i].Clicks {
+			t.Errorf("slot %d = %s with %d click(s), want %s with %d", i, slots[i].Start.UTC(), slots[i].Clicks, want[i].Start, want[i].Clicks)
+		}
+	}
+
+	// Les créneaux sont alignés sur l'époque Unix, et non sur from.
+	slots, err = repo.CountClicksBySlot(ctx, link.ID, from.Add(10*time.Minute), to, time.Hour)
+	if err != nil {
+		t.Fatalf("CountClicksBySlot: %v", err)
+	}
+	if len(slots) != 1 || !slots[0].Start.Equal(from) || slots[0].Clicks != 3 {
+		t.Errorf("hourly slots = %+v, want 3 clicks starting at %s", slots, from)
+	}
+
+	for _, slot := range []time.Duration{0, 500 * time.Millisecond} {
+		if _, err := repo.CountClicksBySlot(ctx, link.ID, from, to, slot); err == nil {
+			t.Errorf("CountClicksBySlot with a %s slot succeeded, want an error", slot)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// erasureColumns associe chaque critère d'effacement à la colonne des clics comparée.
+var erasureColumns = map[string]string{
+	models.ErasureByIP:          "ip_address",
+	models.ErasureByVisitorHash: "visitor_hash",
+}
+
+// ErasureRepository définit les méthodes d'effacement des clics et de consultation de leur audit.
+type ErasureRepository interface {
+	EraseClicks(ctx context.Context, values []string, audit *models.ErasureAudit) error
+	ListErasureAudits(ctx context.Context, limit int) ([]models.ErasureAudit, error)
+}
+
+// GormErasureRepository implémente ErasureRepository avec GORM.
+type GormErasureRepository struct {
+	db *gorm.DB
+}
+
+// NewErasureRepository crée une nouvelle instance de GormErasureRepository.
+func NewErasureRepository(db *gorm.DB) *GormErasureRepository {
+	return &GormErasureRepository{db: db}
+}
+
+// EraseClicks supprime les clics dont la colonne du critère de l'audit vaut l'une des valeurs,
+// puis enregistre l'audit avec le nombre de clics supprimés, dans une seule transaction.
+func (r *GormErasureRepository) EraseClicks(ctx context.Context, values []string, audit *models.ErasureAudit) error {
+	column, ok := erasureColumns[audit.Criterion]
+	if !ok {
+		return fmt.Errorf("unknown erasure criterion %q", audit.Criterion)
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where(column+" IN ?", values).Delete(&models.Click{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete clicks: %w", result.Error)
+		}
+		audit.DeletedCount = result.RowsAffected
+		if err := tx.Create(audit).Error; err != nil {
+			return fmt.Errorf("failed to record erasure audit: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListErasureAudits récupère les derniers audits d'effacement, du plus récent au plus ancien.
+func (r *GormErasureRepository) ListErasureAudits(ctx context.Context, limit int) ([]models.ErasureAudit, error) {
+	var audits []models.ErasureAudit
+	err := r.db.WithContext(ctx).Order("id DESC").Limit(limit).Find(&audits).Error
+	return audits, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrLinkDestinationChanged indique qu'une vérification n'a pas été enregistrée car le lien a changé d'URL longue,
+// ou a été supprimé, pendant qu'elle était en cours.
+var ErrLinkDestinationChanged = errors.New("link destination changed during the check")
+
+// LinkCheckRepository définit les méthodes d'accès à l'historique des vérifications des liens.
+type LinkCheckRepository interface {
+	RecordCheck(ctx context.Context, check *models.LinkCheck, checkedURL string, consecutiveFailures int) error
+	ListChecks(ctx context.Context, linkID uint, since time.Time) ([]models.LinkCheck, error)
+	DeleteChecksBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// GormLinkCheckRepository implémente LinkCheckRepository avec GORM.
+type GormLinkCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkCheckRepository crée une nouvelle instance de GormLinkCheckRepository.
+func NewLinkCheckRepository(db *gorm.DB) *GormLinkCheckRepository {
+	return &GormLinkCheckRepository{db: db}
+}
+
+// RecordCheck enregistre une vérification de checkedURL et reporte l'état retenu (check.LinkStatus) et le nombre
+// d'échecs consécutifs sur le lien, dans une seule transaction. La date de modification du lien n'est pas touchée.
+// Si l'URL longue du lien n'est plus checkedURL, rien n'est enregistré et ErrLinkDestinationChanged est renvoyée :
+// le résultat concerne l'ancienne destination et ne doit pas écraser l'état réinitialisé de la nouvelle.
+func (r *GormLinkCheckRepository) RecordCheck(ctx context.Context, check *models.LinkCheck, checkedURL string, consecutiveFailures int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Link{}).Where("id = ? AND long_url = ?", check.LinkID, checkedURL).UpdateColumns(map[string]any{
+			"health_status":        check.DeclaredStatus(),
+			"last_checked_at":      check.CheckedAt,
+			"consecutive_failures": consecutiveFailures,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update link health: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrLinkDestinationChanged
+		}
+		if err := tx.Create(check).Error; err != nil {
+			return fmt.Errorf("failed to save link check: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListChecks récupère les vérifications d'un lien effectuées depuis since, de la plus ancienne à la plus récente.
+func (r *GormLinkCheckRepository) ListChecks(ctx context.Context, linkID uint, since time.Time) ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	err := r.db.WithContext(ctx).
+		Where("link_id = ? AND checked_at >= ?", linkID, dbTime(since)).
+		Order("checked_at ASC, id ASC").
+		Find(&checks).Error
+	return checks, err
+}
+
+// DeleteChecksBefore supprime les vérifications antérieures à before et renvoie leur nombre.
+func (r *GormLinkCheckRepository) DeleteChecksBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("checked_at < ?", dbTime(before)).Delete(&models.LinkCheck{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB crée une base SQLite migrée dans un répertoire temporaire.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	err = db.AutoMigrate(&models.Link{}, &models.Click{}, &models.VisitorSalt{},
+		&models.ClickRollup{}, &models.ClickDimensionRollup{}, &models.ErasureAudit{}, &models.LinkCheck{}, &models.VisitorSketch{})
+	if err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createTestLink enregistre un lien actif vers longURL.
+func createTestLink(t *testing.T, db *gorm.DB, shortCode, longURL string) *models.Link {
+	t.Helper()
+	link := &models.Link{ShortCode: shortCode, LongURL: longURL, IsActive: true}
+	if err := NewLinkRepository(db).CreateLink(context.Background(), link); err != nil {
+		t.Fatalf("CreateLink: %v", err)
+	}
+	return link
+}
+
+func TestRecordCheckDiscardsChecksOfAReplacedDestination(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	links := NewLinkRepository(db)
+	checks := NewLinkCheckRepository(db)
+	link := createTestLink(t, db, "abc123", "https://old.example.com")
+
+	// Une vérification de l'ancienne URL est en cours pendant que l'URL longue change.
+	inFlight := &models.LinkCheck{LinkID: link.ID, CheckedAt: time.Now(), LinkStatus: models.LinkHealthDown}
+	link.LongURL = "https://new.example.com"
+	if err := links.UpdateLinkDestination(ctx, link); err != nil {
+		t.Fatalf("UpdateLinkDestination: %v", err)
+	}
+
+	err := checks.RecordCheck(ctx, inFlight, "https://old.example.com", 3)
+	if !errors.Is(err, ErrLinkDestinationChanged) {
+		t.Fatalf("RecordCheck error = %v, want ErrLinkDestinationChanged", err)
+	}
+	stored, err := links.GetLinkByShortCode(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("GetLinkByShortCode: %v", err)
+	}
+	if stored.HealthStatus != models.LinkHealthUnknown || stored.ConsecutiveFailures != 0 {
+		t.Errorf("health = %s with %d failure(s), want unknown with 0", stored.HealthStatus, stored.ConsecutiveFailures)
+	}
+	var count int64
+	db.Model(&models.LinkCheck{}).Count(&count)
+	if count != 0 {
+		t.Errorf("%d check(s) saved, want 0", count)
+	}
+
+	// Une vérification de la nouvelle URL est enregistrée.
+	current := &models.LinkCheck{LinkID: link.ID, CheckedAt: time.Now(), Accessible: true, LinkStatus: models.LinkHealthUp}
+	if err := checks.RecordCheck(ctx, current, "https://new.example.com", 0); err != nil {
+		t.Fatalf("RecordCheck: %v", err)
+	}
+	stored, _ = links.GetLinkByShortCode(ctx, "abc123")
+	if stored.HealthStatus != models.LinkHealthUp {
+		t.Errorf("health = %s, want up", stored.HealthStatus)
+	}
+}
+
+func TestUpdateLinkKeepsHealthState(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	links := NewLinkRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	db.Model(link).UpdateColumns(map[string]any{"health_status": models.LinkHealthDown, "consecutive_failures": 4})
+
+	// Une copie lue avant la vérification ne doit pas écraser l'état enregistré par le moniteur.
+	link.IsActive = false
+	if err := links.UpdateLink(ctx, link); err != nil {
+		t.Fatalf("UpdateLink: %v", err)
+	}
+	stored, _ := links.GetLinkByShortCode(ctx, "abc123")
+	if stored.HealthStatus != models.LinkHealthDown || stored.ConsecutiveFailures != 4 {
+		t.Errorf("health = %s with %d failure(s), want down with 4", stored.HealthStatus, stored.ConsecutiveFailures)
+	}
+}
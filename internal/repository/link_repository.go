@@ -1,15 +1,56 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
 
+// ErrDuplicateShortCode indique qu'un lien utilise déjà le code court demandé.
+var ErrDuplicateShortCode = errors.New("short code already exists")
+
+// Critères de tri acceptés par ListLinks.
+const (
+	SortByCreated = "created"
+	SortByClicks  = "clicks"
+)
+
+// LinkCursor repère la position du dernier élément renvoyé dans une liste paginée.
+type LinkCursor struct {
+	ClickCount int  `json:"c,omitempty"`
+	ID         uint `json:"id"`
+}
+
+// LinkListQuery décrit les filtres, le tri et la pagination d'une liste de liens.
+type LinkListQuery struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	IsActive    *bool
+	Domain      string // Domaine exact de l'URL de destination, comparé à Link.Host.
+	Search      string // Sous-chaîne recherchée dans l'URL de destination.
+	SortBy      string // SortByCreated ou SortByClicks.
+	Ascending   bool
+	After       *LinkCursor
+	Limit       int
+}
+
 // LinkRepository définit les méthodes d'accès aux données pour les liens.
 type LinkRepository interface {
-	CreateLink(link *models.Link) error
-	GetLinkByShortCode(shortCode string) (*models.Link, error)
-	GetAllLinks() ([]models.Link, error)
+	CreateLink(ctx context.Context, link *models.Link) error
+	GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error)
+	ShortCodeExists(ctx context.Context, shortCode string) (bool, error)
+	GetAllLinks(ctx context.Context) ([]models.Link, error)
+	ListLinks(ctx context.Context, query LinkListQuery) ([]models.LinkWithClickCount, error)
+	UpdateLink(ctx context.Context, link *models.Link) error
+	UpdateLinkDestination(ctx context.Context, link *models.Link) error
+	DeleteLink(ctx context.Context, link *models.Link) error
+	GetDeletedLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error)
+	RestoreLink(ctx context.Context, link *models.Link) error
 }
 
 // GormLinkRepository implémente LinkRepository avec GORM.
@@ -23,23 +64,169 @@ func NewLinkRepository(db *gorm.DB) *GormLinkRepository {
 }
 
 // CreateLink insère un nouveau lien dans la base de données.
-func (r *GormLinkRepository) CreateLink(link *models.Link) error {
-	return r.db.Create(link).Error
+func (r *GormLinkRepository) CreateLink(ctx context.Context, link *models.Link) error {
+	link.Host = models.URLHost(link.LongURL)
+	err := r.db.WithContext(ctx).Create(link).Error
+	if err != nil && isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicateShortCode, err)
+	}
+	return err
 }
 
 // GetLinkByShortCode récupère un lien par son code court.
-func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+func (r *GormLinkRepository) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
 	var link models.Link
-	err := r.db.Where("short_code = ?", shortCode).First(&link).Error
+	err := r.db.WithContext(ctx).Where("short_code = ?", shortCode).First(&link).Error
 	if err != nil {
 		return nil, err
 	}
 	return &link, nil
 }
 
+// ShortCodeExists indique si un code court est déjà pris, y compris par un lien supprimé.
+func (r *GormLinkRepository) ShortCodeExists(ctx context.Context, shortCode string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Link{}).Where("short_code = ?", shortCode).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // GetAllLinks récupère tous les liens de la base de données.
-func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
+func (r *GormLinkRepository) GetAllLinks(ctx context.Context) ([]models.Link, error) {
 	var links []models.Link
-	err := r.db.Find(&links).Error
+	err := r.db.WithContext(ctx).Find(&links).Error
+	return links, err
+}
+
+// ListLinks récupère une page de liens filtrés et triés, avec leur nombre de clics.
+// La pagination par curseur (keyset) reste efficace quelle que soit la profondeur de la page.
+func (r *GormLinkRepository) ListLinks(ctx context.Context, query LinkListQuery) ([]models.LinkWithClickCount, error) {
+	inner := r.db.WithContext(ctx).Model(&models.Link{}).
+		Select(`links.*, (SELECT COUNT(*) FROM clicks WHERE clicks.link_id = links.id)
+			+ (SELECT COALESCE(SUM(clicks), 0) FROM click_rollups WHERE click_rollups.link_id = links.id) AS click_count`)
+	if query.CreatedFrom != nil {
+		inner = inner.Where("links.created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		inner = inner.Where("links.created_at < ?", *query.CreatedTo)
+	}
+	if query.IsActive != nil {
+		inner = inner.Where("links.is_active = ?", *query.IsActive)
+	}
+	if query.Domain != "" {
+		inner = inner.Where("links.host = ?", strings.ToLower(query.Domain))
+	}
+	if query.Search != "" {
+		inner = inner.Where(`links.long_url LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Search)+"%")
+	}
+
+	direction, comparator := "DESC", "<"
+	if query.Ascending {
+		direction, comparator = "ASC", ">"
+	}
+
+	outer := r.db.WithContext(ctx).Table("(?) AS l", inner)
+	if query.SortBy == SortByClicks {
+		if query.After != nil {
+			outer = outer.Where(fmt.Sprintf("l.click_count %[1]s ? OR (l.click_count = ? AND l.id %[1]s ?)", comparator),
+				query.After.ClickCount, query.After.ClickCount, query.After.ID)
+		}
+		outer = outer.Order("l.click_count " + direction).Order("l.id " + direction)
+	} else {
+		// Les identifiants étant attribués dans l'ordre de création, ils servent de clé de tri.
+		if query.After != nil {
+			outer = outer.Where("l.id "+comparator+" ?", query.After.ID)
+		}
+		outer = outer.Order("l.id " + direction)
+	}
+
+	var links []models.LinkWithClickCount
+	err := outer.Limit(query.Limit).Find(&links).Error
 	return links, err
 }
+
+// UpdateLink enregistre les modifications d'un lien existant.
+func (r *GormLinkRepository) UpdateLink(ctx context.Context, link *models.Link) error {
+	// L'état de santé appartient au moniteur : une copie lue avant sa dernière vérification ne doit pas l'écraser.
+	link.Host = models.URLHost(link.LongURL)
+	return r.db.WithContext(ctx).Omit("health_status", "last_checked_at", "consecutive_failures").Save(link).Error
+}
+
+// UpdateLinkDestination enregistre les modifications d'un lien dont l'URL longue a changé et remet son état
+// de santé à inconnu, sans vérification ni échec enregistré, dans une seule transaction : l'état constaté
+// concernait l'ancienne URL.
+func (r *GormLinkRepository) UpdateLinkDestination(ctx context.Context, link *models.Link) error {
+	link.Host = models.URLHost(link.LongURL)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("health_status", "last_checked_at", "consecutive_failures").Save(link).Error; err != nil {
+			return err
+		}
+		return tx.Model(link).UpdateColumns(map[string]any{
+			"health_status":        models.LinkHealthUnknown,
+			"last_checked_at":      nil,
+			"consecutive_failures": 0,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	link.HealthStatus = models.LinkHealthUnknown
+	link.LastCheckedAt = nil
+	link.ConsecutiveFailures = 0
+	return nil
+}
+
+// DeleteLink supprime un lien de manière logique (soft delete) en conservant ses clics.
+func (r *GormLinkRepository) DeleteLink(ctx context.Context, link *models.Link) error {
+	return r.db.WithContext(ctx).Delete(link).Error
+}
+
+// GetDeletedLinkByShortCode récupère un lien supprimé logiquement par son code court.
+func (r *GormLinkRepository) GetDeletedLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	var link models.Link
+	err := r.db.WithContext(ctx).Unscoped().Where("short_code = ? AND deleted_at IS NOT NULL", shortCode).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// RestoreLink annule la suppression logique d'un lien.
+func (r *GormLinkRepository) RestoreLink(ctx context.Context, link *models.Link) error {
+	err := r.db.WithContext(ctx).Unscoped().Model(link).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+	link.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// BackfillLinkHosts renseigne l'hôte des liens enregistrés avant l'ajout de la colonne, supprimés compris,
+// et renvoie leur nombre.
+func (r *GormLinkRepository) BackfillLinkHosts(ctx context.Context) (int, error) {
+	var links []models.Link
+	err := r.db.WithContext(ctx).Unscoped().Select("id", "long_url").Where("host = '' OR host IS NULL").Find(&links).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, link := range links {
+		err := r.db.WithContext(ctx).Unscoped().Model(&models.Link{}).Where("id = ?", link.ID).
+			UpdateColumn("host", models.URLHost(link.LongURL)).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(links), nil
+}
+
+// escapeLike échappe les caractères spéciaux d'un motif LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// isUniqueViolation détecte une violation de contrainte d'unicité SQLite.
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
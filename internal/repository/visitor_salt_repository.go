@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VisitorSaltRepository définit les méthodes d'accès aux sels quotidiens des empreintes de visiteurs.
+type VisitorSaltRepository interface {
+	GetOrCreateSalt(ctx context.Context, day string, candidate []byte) ([]byte, error)
+	DeleteSaltsBefore(ctx context.Context, day string) error
+}
+
+// GormVisitorSaltRepository implémente VisitorSaltRepository avec GORM.
+type GormVisitorSaltRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitorSaltRepository crée une nouvelle instance de GormVisitorSaltRepository.
+func NewVisitorSaltRepository(db *gorm.DB) *GormVisitorSaltRepository {
+	return &GormVisitorSaltRepository{db: db}
+}
+
+// GetOrCreateSalt renvoie le sel du jour donné, en enregistrant candidate s'il n'existe pas encore.
+// Si un autre processus a déjà créé le sel, c'est le sien qui est renvoyé.
+func (r *GormVisitorSaltRepository) GetOrCreateSalt(ctx context.Context, day string, candidate []byte) ([]byte, error) {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.VisitorSalt{Day: day, Salt: candidate}).Error
+	if err != nil {
+		return nil, err
+	}
+	var salt models.VisitorSalt
+	if err := r.db.WithContext(ctx).Where("day = ?", day).First(&salt).Error; err != nil {
+		return nil, err
+	}
+	return salt.Salt, nil
+}
+
+// DeleteSaltsBefore supprime les sels des jours antérieurs au jour donné.
+func (r *GormVisitorSaltRepository) DeleteSaltsBefore(ctx context.Context, day string) error {
+	return r.db.WithContext(ctx).Where("day < ?", day).Delete(&models.VisitorSalt{}).Error
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/hll"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VisitorSketchPrecision donne 4096 registres par sketch, soit une erreur type d'environ 1,6 %.
+const VisitorSketchPrecision = 12
+
+// VisitorSketchRepository définit les méthodes d'accès aux sketches quotidiens des visiteurs uniques.
+type VisitorSketchRepository interface {
+	RecordVisitorSketches(ctx context.Context, clicks []models.Click) error
+	GetVisitorSketches(ctx context.Context, linkID uint) ([]models.VisitorSketch, error)
+}
+
+// GormVisitorSketchRepository implémente VisitorSketchRepository avec GORM.
+type GormVisitorSketchRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitorSketchRepository crée une nouvelle instance de GormVisitorSketchRepository.
+func NewVisitorSketchRepository(db *gorm.DB) *GormVisitorSketchRepository {
+	return &GormVisitorSketchRepository{db: db}
+}
+
+// sketchKey identifie le sketch d'un lien pour une journée (UTC).
+type sketchKey struct {
+	linkID uint
+	day    string
+}
+
+// RecordVisitorSketches ajoute les empreintes de visiteurs des clics aux sketches de leur lien et de leur jour (UTC).
+// Chaque sketch est lu puis réécrit : les appels concurrents doivent être sérialisés par l'appelant.
+func (r *GormVisitorSketchRepository) RecordVisitorSketches(ctx context.Context, clicks []models.Click) error {
+	sketches := make(map[sketchKey]*hll.Sketch)
+	for _, click := range clicks {
+		if click.VisitorHash == "" {
+			continue
+		}
+		key := sketchKey{linkID: click.LinkID, day: click.Timestamp.UTC().Format(time.DateOnly)}
+		sketch, ok := sketches[key]
+		if !ok {
+			sketch = hll.New(VisitorSketchPrecision)
+			sketches[key] = sketch
+		}
+		sketch.Add(visitorHashPrefix(click.VisitorHash))
+	}
+	if len(sketches) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for key, sketch := range sketches {
+			if err := mergeSketch(tx, key, sketch); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetVisitorSketches récupère les sketches d'un lien, du jour le plus ancien au plus récent.
+func (r *GormVisitorSketchRepository) GetVisitorSketches(ctx context.Context, linkID uint) ([]models.VisitorSketch, error) {
+	var sketches []models.VisitorSketch
+	err := r.db.WithContext(ctx).Where("link_id = ?", linkID).Order("day").Find(&sketches).Error
+	return sketches, err
+}
+
+// BackfillVisitorSketches calcule les sketches des jours qui n'en ont pas encore à partir des empreintes
+// des clics bruts, et renvoie leur nombre. Les jours déjà agrégés et purgés restent sans sketch.
+// Elle ne doit pas être exécutée pendant que des workers enregistrent des clics.
+func (r *GormVisitorSketchRepository) BackfillVisitorSketches(ctx context.Context) (int, error) {
+	var keys []struct {
+		LinkID uint
+		Day    string
+	}
+	err := r.db.WithContext(ctx).Model(&models.Click{}).
+		Select("DISTINCT link_id, date(timestamp) AS day").
+		Where("visitor_hash <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM visitor_sketches s WHERE s.link_id = clicks.link_id AND s.day = date(clicks.timestamp))").
+		Order("link_id, day").
+		Scan(&keys).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		// Un jour à la fois : la mémoire reste bornée par la taille d'un sketch.
+		sketch := hll.New(VisitorSketchPrecision)
+		rows, err := r.db.WithContext(ctx).Model(&models.Click{}).
+			Select("visitor_hash").
+			Where("link_id = ? AND date(timestamp) = ? AND visitor_hash <> ''", key.LinkID, key.Day).
+			Rows()
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			var visitorHash string
+			if err := rows.Scan(&visitorHash); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			sketch.Add(visitorHashPrefix(visitorHash))
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return 0, err
+		}
+		if err := mergeSketch(r.db.WithContext(ctx), sketchKey{linkID: key.LinkID, day: key.Day}, sketch); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// mergeSketch fusionne sketch avec le sketch enregistré pour key, puis enregistre le résultat.
+func mergeSketch(tx *gorm.DB, key sketchKey, sketch *hll.Sketch) error {
+	var stored []models.VisitorSketch
+	if err := tx.Where("link_id = ? AND day = ?", key.linkID, key.day).Limit(1).Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to load visitor sketch: %w", err)
+	}
+	if len(stored) == 1 {
+		existing, err := hll.FromRegisters(stored[0].Registers)
+		if err != nil {
+			return fmt.Errorf("invalid visitor sketch for link %d on %s: %w", key.linkID, key.day, err)
+		}
+		if err := sketch.Merge(existing); err != nil {
+			return fmt.Errorf("invalid visitor sketch for link %d on %s: %w", key.linkID, key.day, err)
+		}
+	}
+	err := tx.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.VisitorSketch{LinkID: key.linkID, Day: key.day, Registers: sketch.Registers()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to save visitor sketch: %w", err)
+	}
+	return nil
+}
+
+// visitorHashPrefix convertit les 8 premiers octets d'une empreinte SHA-256 hexadécimale en entier 64 bits.
+func visitorHashPrefix(visitorHash string) uint64 {
+	raw, err := hex.DecodeString(visitorHash)
+	if err != nil || len(raw) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(raw[:8])
+}
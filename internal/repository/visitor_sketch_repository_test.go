@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/hll"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// visitorClicks renvoie un clic par visiteur, de first à last inclus, le jour donné.
+func visitorClicks(linkID uint, day time.Time, first, last int) []models.Click {
+	var clicks []models.Click
+	for i := first; i <= last; i++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("visitor-%d", i)))
+		clicks = append(clicks, models.Click{LinkID: linkID, Timestamp: day, VisitorHash: hex.EncodeToString(sum[:])})
+	}
+	return clicks
+}
+
+// estimates renvoie l'estimation de chaque sketch d'un lien, par jour.
+func estimates(t *testing.T, repo *GormVisitorSketchRepository, linkID uint) map[string]uint64 {
+	t.Helper()
+	sketches, err := repo.GetVisitorSketches(context.Background(), linkID)
+	if err != nil {
+		t.Fatalf("GetVisitorSketches: %v", err)
+	}
+	got := make(map[string]uint64, len(sketches))
+	for _, stored := range sketches {
+		sketch, err := hll.FromRegisters(stored.Registers)
+		if err != nil {
+			t.Fatalf("FromRegisters(%s): %v", stored.Day, err)
+		}
+		got[stored.Day] = sketch.Estimate()
+	}
+	return got
+}
+
+// near indique si une estimation est à moins de 5 % de la valeur attendue.
+func near(estimate, want uint64) bool {
+	return estimate*100 >= want*95 && estimate*100 <= want*105+100
+}
+
+func TestRecordVisitorSketchesMergesBatchesPerDay(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewVisitorSketchRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	day := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	// Deux lots se recouvrant le même jour, un troisième le lendemain, et un clic sans empreinte.
+	batches := [][]models.Click{
+		visitorClicks(link.ID, day, 1, 300),
+		visitorClicks(link.ID, day.Add(8*time.Hour), 201, 500),
+		visitorClicks(link.ID, day.AddDate(0, 0, 1), 1, 100),
+		{{LinkID: link.ID, Timestamp: day}},
+	}
+	for _, batch := range batches {
+		if err := repo.RecordVisitorSketches(ctx, batch); err != nil {
+			t.Fatalf("RecordVisitorSketches: %v", err)
+		}
+	}
+
+	got := estimates(t, repo, link.ID)
+	want := map[string]uint64{"2026-10-17": 500, "2026-10-18": 100}
+	if len(got) != len(want) {
+		t.Fatalf("sketches = %v, want days %v", got, want)
+	}
+	for day, visitors := range want {
+		if estimate := got[day]; !near(estimate, visitors) {
+			t.Errorf("estimate for %s = %d, want about %d", day, estimate, visitors)
+		}
+	}
+}
+
+func TestBackfillVisitorSketchesOnlyFillsMissingDays(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewVisitorSketchRepository(db)
+	link := createTestLink(t, db, "abc123", "https://example.com")
+	day := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	clicks := append(visitorClicks(link.ID, day, 1, 200), visitorClicks(link.ID, day.AddDate(0, 0, 1), 1, 50)...)
+	if err := NewClickRepository(db).CreateClicks(ctx, clicks); err != nil {
+		t.Fatalf("CreateClicks: %v", err)
+	}
+	// Le premier jour a déjà un sketch, tenu par les workers.
+	if err := repo.RecordVisitorSketches(ctx, visitorClicks(link.ID, day, 1, 10)); err != nil {
+		t.Fatalf("RecordVisitorSketches: %v", err)
+	}
+
+	count, err := repo.BackfillVisitorSketches(ctx)
+	if err != nil {
+		t.Fatalf("BackfillVisitorSketches: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("backfilled %d sketch(es), want 1", count)
+	}
+	got := estimates(t, repo, link.ID)
+	if len(got) != 2 || !near(got["2026-10-17"], 10) || !near(got["2026-10-18"], 50) {
+		t.Errorf("estimates = %v, want about 10 on 2026-10-17 and 50 on 2026-10-18", got)
+	}
+	if count, _ := repo.BackfillVisitorSketches(ctx); count != 0 {
+		t.Errorf("second backfill computed %d sketch(es), want 0", count)
+	}
+}
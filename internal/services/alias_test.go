@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		alias string
+		want  error
+	}{
+		{"promo", nil},
+		{"Black-Friday_2026", nil},
+		{"abc", nil},
+		{strings.Repeat("a", maxAliasLength), nil},
+		{"ab", ErrInvalidAlias},
+		{strings.Repeat("a", maxAliasLength+1), ErrInvalidAlias},
+		{"", ErrInvalidAlias},
+		{"with space", ErrInvalidAlias},
+		{"slash/path", ErrInvalidAlias},
+		{"dot.ted", ErrInvalidAlias},
+		{"accentué", ErrInvalidAlias},
+		{"health", ErrReservedAlias},
+		{"livez", ErrReservedAlias},
+		{"readyz", ErrReservedAlias},
+		{"metrics", ErrReservedAlias},
+		{"api", ErrReservedAlias},
+		{"Admin", ErrReservedAlias},
+		{"STATIC", ErrReservedAlias},
+		{"healthy", nil},
+	}
+	for _, tt := range tests {
+		err := ValidateAlias(tt.alias)
+		if tt.want == nil && err != nil {
+			t.Errorf("ValidateAlias(%q) = %v, want nil", tt.alias, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("ValidateAlias(%q) = %v, want %v", tt.alias, err, tt.want)
+		}
+	}
+}
+
+func TestIsReserved(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"health", true},
+		{"Health", true},
+		{"readyz", true},
+		{"static", true},
+		{"h3alth", false},
+		{"aB3xYz", false},
+	}
+	for _, tt := range tests {
+		if got := isReserved(tt.code); got != tt.want {
+			t.Errorf("isReserved(%q) = %t, want %t", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateShortCode(t *testing.T) {
+	service := NewLinkService(nil, nil)
+	for _, length := range []int{1, 6, 12} {
+		code, err := service.GenerateShortCode(length)
+		if err != nil {
+			t.Fatalf("GenerateShortCode(%d): %v", length, err)
+		}
+		if len(code) != length || strings.Trim(code, charset) != "" {
+			t.Errorf("GenerateShortCode(%d) = %q", length, code)
+		}
+	}
+}
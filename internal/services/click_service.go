@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 )
@@ -18,11 +20,11 @@ func NewClickService(clickRepo repository.ClickRepository) *ClickService {
 }
 
 // RecordClick enregistre un nouvel événement de clic.
-func (s *ClickService) RecordClick(click *models.Click) error {
-	return s.clickRepo.CreateClick(click)
+func (s *ClickService) RecordClick(ctx context.Context, click *models.Click) error {
+	return s.clickRepo.CreateClick(ctx, click)
 }
 
 // GetClicksCountByLinkID récupère le nombre total de clics pour un lien donné.
-func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
-	return s.clickRepo.CountClicksByLinkID(linkID)
+func (s *ClickService) GetClicksCountByLinkID(ctx context.Context, linkID uint) (int, error) {
+	return s.clickRepo.CountClicksByLinkID(ctx, linkID)
 }
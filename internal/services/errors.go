@@ -0,0 +1,31 @@
+package services
+
+import "errors"
+
+// Erreurs métier renvoyées par les services, exploitables via errors.Is.
+var (
+	// ErrInvalidAlias indique un alias personnalisé au format invalide.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrReservedAlias indique un alias qui entre en conflit avec une route du service.
+	ErrReservedAlias = errors.New("alias is reserved")
+	// ErrAliasTaken indique un alias déjà utilisé par un autre lien.
+	ErrAliasTaken = errors.New("alias already in use")
+	// ErrInvalidExpiration indique une date d'expiration ou un budget de clics incohérent.
+	ErrInvalidExpiration = errors.New("invalid expiration")
+	// ErrInvalidListQuery indique des paramètres de liste (tri, curseur, limite) invalides.
+	ErrInvalidListQuery = errors.New("invalid list query")
+	// ErrInvalidTimeRange indique une période ou un intervalle d'agrégation invalide.
+	ErrInvalidTimeRange = errors.New("invalid time range")
+	// ErrLinkDisabled indique un lien désactivé manuellement.
+	ErrLinkDisabled = errors.New("link is disabled")
+	// ErrLinkExpired indique un lien dont la date d'expiration est dépassée.
+	ErrLinkExpired = errors.New("link has expired")
+	// ErrLinkExhausted indique un lien dont le budget de clics est épuisé.
+	ErrLinkExhausted = errors.New("link click budget exhausted")
+	// ErrInvalidFailover indique une politique de secours inconnue ou une URL de secours invalide ou manquante.
+	ErrInvalidFailover = errors.New("invalid failover settings")
+	// ErrDestinationUnavailable indique une URL longue déclarée inaccessible, pour laquelle le lien affiche une page d'indisponibilité.
+	ErrDestinationUnavailable = errors.New("destination is unavailable")
+	// ErrInvalidErasureRequest indique une demande d'effacement sans critère unique et valide.
+	ErrInvalidErasureRequest = errors.New("invalid erasure request")
+)
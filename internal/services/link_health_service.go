@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Bornes de la période de l'historique de santé d'un lien.
+const (
+	defaultHealthDays = 7
+	maxHealthDays     = 90
+	// maxHealthTransitions est le nombre de changements d'état les plus récents renvoyés.
+	maxHealthTransitions = 20
+)
+
+// HealthTransition décrit un changement de l'état retenu pour un lien entre deux vérifications successives.
+type HealthTransition struct {
+	At         time.Time `json:"at"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	StatusCode int       `json:"status_code,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+}
+
+// LinkHealth résume l'état de santé d'un lien et son historique sur une période.
+type LinkHealth struct {
+	Status        string     `json:"status"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	From          time.Time  `json:"from"`
+	Days          int        `json:"days"`
+	Checks        int        `json:"checks"`
+	// ConsecutiveFailures est le nombre de vérifications en échec depuis la dernière réussie.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// UptimePercent est la part des vérifications réussies sur la période ; nil sans vérification.
+	UptimePercent *float64          `json:"uptime_percent"`
+	AvgLatencyMs  int64             `json:"avg_latency_ms"`
+	LastCheck     *models.LinkCheck `json:"last_check,omitempty"`
+	// Transitions liste les derniers changements d'état, du plus récent au plus ancien.
+	Transitions []HealthTransition `json:"transitions"`
+}
+
+// LinkHealthService fournit l'état de santé des liens enregistré par le moniteur d'URLs.
+type LinkHealthService struct {
+	linkRepo  repository.LinkRepository
+	checkRepo repository.LinkCheckRepository
+}
+
+// NewLinkHealthService crée une nouvelle instance de LinkHealthService.
+func NewLinkHealthService(linkRepo repository.LinkRepository, checkRepo repository.LinkCheckRepository) *LinkHealthService {
+	return &LinkHealthService{
+		linkRepo:  linkRepo,
+		checkRepo: checkRepo,
+	}
+}
+
+// GetLinkHealth renvoie l'état de santé d'un lien et son historique sur les days derniers jours
+// (7 par défaut, 90 au plus).
+func (s *LinkHealthService) GetLinkHealth(ctx context.Context, shortCode string, days int) (*models.Link, *LinkHealth, error) {
+	if days <= 0 {
+		days = defaultHealthDays
+	}
+	if days > maxHealthDays {
+		return nil, nil, fmt.Errorf("%w: at most %d days of health history", ErrInvalidTimeRange, maxHealthDays)
+	}
+
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	from := time.Now().AddDate(0, 0, -days)
+	checks, err := s.checkRepo.ListChecks(ctx, link.ID, from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list link checks: %w", err)
+	}
+
+	report := &LinkHealth{
+		Status:              link.HealthStatus,
+		LastCheckedAt:       link.LastCheckedAt,
+		ConsecutiveFailures: link.ConsecutiveFailures,
+		From:                from,
+		Days:                days,
+		Checks:              len(checks),
+		Transitions:         []HealthTransition{},
+	}
+	if len(checks) == 0 {
+		return link, report, nil
+	}
+
+	var up int
+	var totalLatency int64
+	for i, check := range checks {
+		if check.Accessible {
+			up++
+		}
+		totalLatency += check.LatencyMs
+		// La première vérification de la période n'a pas de précédent connu : elle ne compte pas comme transition,
+		// pas plus que la détermination de l'état initial d'un lien.
+		if i == 0 {
+			continue
+		}
+		from, to := checks[i-1].DeclaredStatus(), check.DeclaredStatus()
+		if from != to && from != models.LinkHealthUnknown && to != models.LinkHealthUnknown {
+			report.Transitions = append(report.Transitions, HealthTransition{
+				At:         check.CheckedAt,
+				From:       from,
+				To:         to,
+				StatusCode: check.StatusCode,
+				ErrorClass: check.ErrorClass,
+			})
+		}
+	}
+	uptime := float64(up) * 100 / float64(len(checks))
+	report.UptimePercent = &uptime
+	report.AvgLatencyMs = totalLatency / int64(len(checks))
+	report.LastCheck = &checks[len(checks)-1]
+
+	// Ne garde que les transitions les plus récentes, en commençant par la dernière.
+	transitions := report.Transitions[max(0, len(report.Transitions)-maxHealthTransitions):]
+	for i, j := 0, len(transitions)-1; i < j; i, j = i+1, j-1 {
+		transitions[i], transitions[j] = transitions[j], transitions[i]
+	}
+	report.Transitions = transitions
+	return link, report, nil
+}
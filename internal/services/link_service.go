@@ -1,15 +1,21 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
-	"gorm.io/gorm"
-
+	"github.com/axellelanca/urlshortener/internal/logging"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 )
@@ -17,10 +23,107 @@ import (
 // Jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Bornes de longueur acceptées pour un alias personnalisé.
+const (
+	minAliasLength = 3
+	maxAliasLength = 32
+)
+
+// aliasPattern restreint les alias aux caractères sûrs dans un chemin d'URL.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// reservedAliases liste les codes courts interdits, alias comme codes générés, car ils masqueraient des routes du service.
+var reservedAliases = map[string]struct{}{
+	"health":  {},
+	"api":     {},
+	"admin":   {},
+	"static":  {},
+	"metrics": {},
+	"livez":   {},
+	"readyz":  {},
+}
+
+// CreateLinkOptions regroupe les paramètres optionnels de création d'un lien.
+type CreateLinkOptions struct {
+	// Alias est un code court personnalisé ; un code aléatoire est généré s'il est vide.
+	Alias string
+	// ExpiresAt est la date d'expiration du lien ; nil pour un lien permanent.
+	ExpiresAt *time.Time
+	// MaxClicks est le nombre maximal de clics autorisés ; 0 pour un nombre illimité.
+	MaxClicks int
+	// FallbackURL est l'URL de secours utilisée par la politique redirect_fallback.
+	FallbackURL string
+	// FailoverPolicy est la politique appliquée si l'URL longue est déclarée inaccessible ;
+	// par défaut redirect_fallback si FallbackURL est renseignée, keep sinon.
+	FailoverPolicy string
+}
+
+// UpdateLinkInput décrit une modification partielle d'un lien ; les champs nil sont ignorés.
+type UpdateLinkInput struct {
+	LongURL        *string
+	IsActive       *bool
+	FallbackURL    *string // Une chaîne vide retire l'URL de secours.
+	FailoverPolicy *string
+}
+
+// Bornes de la taille d'une page de liens.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ListLinksInput décrit une demande de page de liens ; Cursor est le curseur opaque de la page précédente.
+type ListLinksInput struct {
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	IsActive    *bool
+	Domain      string
+	Search      string
+	SortBy      string
+	Order       string
+	Cursor      string
+	Limit       int
+}
+
+// LinkPage est une page de liens accompagnée du curseur de la page suivante (vide en fin de liste).
+type LinkPage struct {
+	Links      []models.LinkWithClickCount `json:"links"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+// breakdownLimit est le nombre maximal de valeurs renvoyées par dimension.
+const breakdownLimit = 10
+
+// statsDimensions liste les dimensions ventilées dans les statistiques d'un lien.
+var statsDimensions = []string{
+	repository.DimensionBrowser,
+	repository.DimensionOS,
+	repository.DimensionDevice,
+	repository.DimensionBot,
+	repository.DimensionReferrer,
+	repository.DimensionReferrerDomain,
+	repository.DimensionCountry,
+	repository.DimensionRegion,
+	repository.DimensionCity,
+}
+
+// LinkStats regroupe les statistiques d'un lien.
+type LinkStats struct {
+	TotalClicks int `json:"total_clicks"`
+	// UniqueVisitors est la somme des visiteurs uniques quotidiens : un même visiteur
+	// revenant plusieurs jours est compté une fois par jour.
+	UniqueVisitors            int                                `json:"unique_visitors"`
+	UniqueVisitorsApproximate bool                               `json:"unique_visitors_approximate"`
+	UniqueVisitorsByDay       []models.DailyUniqueVisitors       `json:"unique_visitors_by_day"`
+	Breakdowns                map[string][]models.BreakdownEntry `json:"breakdowns"`
+}
+
 // LinkService fournit la logique métier pour les liens.
 type LinkService struct {
-	linkRepo  repository.LinkRepository
-	clickRepo repository.ClickRepository
+	linkRepo     repository.LinkRepository
+	clickRepo    repository.ClickRepository
+	sketchRepo   repository.VisitorSketchRepository
+	hllThreshold int
 }
 
 // NewLinkService crée une nouvelle instance de LinkService.
@@ -44,70 +147,408 @@ func (s *LinkService) GenerateShortCode(length int) (string, error) {
 	return string(b), nil
 }
 
-// CreateLink crée un nouveau lien raccourci avec un code unique.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+// ValidateAlias vérifie qu'un alias personnalisé respecte le format attendu et n'est pas réservé.
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("%w: length must be between %d and %d characters", ErrInvalidAlias, minAliasLength, maxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: only letters, digits, '-' and '_' are allowed", ErrInvalidAlias)
+	}
+	if isReserved(alias) {
+		return fmt.Errorf("%w: %q", ErrReservedAlias, alias)
+	}
+	return nil
+}
 
-	var shortCode string
-	const maxRetries = 5
+// isReserved indique si un code court, quelle que soit sa casse, figure parmi les noms réservés.
+func isReserved(code string) bool {
+	_, reserved := reservedAliases[strings.ToLower(code)]
+	return reserved
+}
 
-	for i := 0; i < maxRetries; i++ {
-		code, err := s.GenerateShortCode(6)
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate short code: %w", err)
+// CreateLink crée un nouveau lien raccourci, avec l'alias fourni ou un code unique généré.
+func (s *LinkService) CreateLink(ctx context.Context, longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiration date must be in the future", ErrInvalidExpiration)
+	}
+	if opts.MaxClicks < 0 {
+		return nil, fmt.Errorf("%w: max clicks must be positive", ErrInvalidExpiration)
+	}
+	if opts.FailoverPolicy == "" {
+		opts.FailoverPolicy = models.FailoverKeep
+		if opts.FallbackURL != "" {
+			opts.FailoverPolicy = models.FailoverRedirectFallback
 		}
+	}
+	if err := ValidateFailover(opts.FailoverPolicy, opts.FallbackURL); err != nil {
+		return nil, err
+	}
 
-		// Vérifie l'unicité du code généré.
-		_, err = s.linkRepo.GetLinkByShortCode(code)
-
+	var shortCode string
+	if opts.Alias != "" {
+		code, err := s.reserveAlias(ctx, opts.Alias)
 		if err != nil {
-			// Le code est unique si 'record not found'.
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code
-				break
-			}
-			return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+			return nil, err
 		}
-
-		log.Printf("Short code '%s' already exists, retrying generation (%d/%d)...", code, i+1, maxRetries)
-
-	}
-
-	if shortCode == "" {
-		return nil, errors.New("failed to generate unique short code after maximum retries")
+		shortCode = code
+	} else {
+		code, err := s.generateUniqueShortCode(ctx)
+		if err != nil {
+			return nil, err
+		}
+		shortCode = code
 	}
 
 	link := &models.Link{
 		ShortCode: shortCode,
 		LongURL:   longURL,
 		IsActive:  true,
+		ExpiresAt: opts.ExpiresAt,
+		MaxClicks: opts.MaxClicks,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		FallbackURL:    opts.FallbackURL,
+		FailoverPolicy: opts.FailoverPolicy,
 	}
 
-	err := s.linkRepo.CreateLink(link)
+	err := s.linkRepo.CreateLink(ctx, link)
 	if err != nil {
+		// Un autre lien a pu prendre le même code entre la vérification et l'insertion.
+		if errors.Is(err, repository.ErrDuplicateShortCode) {
+			return nil, fmt.Errorf("%w: %q", ErrAliasTaken, shortCode)
+		}
 		return nil, fmt.Errorf("failed to save link to database: %w", err)
 	}
 
 	return link, nil
 }
 
+// reserveAlias valide un alias personnalisé et vérifie qu'il est disponible.
+func (s *LinkService) reserveAlias(ctx context.Context, alias string) (string, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return "", err
+	}
+	exists, err := s.linkRepo.ShortCodeExists(ctx, alias)
+	if err != nil {
+		return "", fmt.Errorf("database error checking alias availability: %w", err)
+	}
+	if exists {
+		return "", fmt.Errorf("%w: %q", ErrAliasTaken, alias)
+	}
+	return alias, nil
+}
+
+// generateUniqueShortCode génère un code aléatoire en réessayant en cas de collision.
+func (s *LinkService) generateUniqueShortCode(ctx context.Context) (string, error) {
+	const maxRetries = 5
+
+	for i := 0; i < maxRetries; i++ {
+		code, err := s.GenerateShortCode(6)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate short code: %w", err)
+		}
+
+		// Écarte les noms réservés puis vérifie l'unicité du code généré.
+		if isReserved(code) {
+			slog.DebugContext(ctx, "generated short code is reserved, retrying generation", logging.KeyShortCode, code, "attempt", i+1, "max_attempts", maxRetries)
+			continue
+		}
+		exists, err := s.linkRepo.ShortCodeExists(ctx, code)
+		if err != nil {
+			return "", fmt.Errorf("database error checking short code uniqueness: %w", err)
+		}
+		if !exists {
+			return code, nil
+		}
+
+		slog.DebugContext(ctx, "short code already exists, retrying generation", logging.KeyShortCode, code, "attempt", i+1, "max_attempts", maxRetries)
+	}
+
+	return "", errors.New("failed to generate unique short code after maximum retries")
+}
+
 // GetLinkByShortCode récupère un lien par son code court.
-func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
-	return s.linkRepo.GetLinkByShortCode(shortCode)
+func (s *LinkService) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	return s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+}
+
+// ListLinks récupère une page de liens non supprimés selon les filtres et le tri demandés.
+func (s *LinkService) ListLinks(ctx context.Context, input ListLinksInput) (*LinkPage, error) {
+	query := repository.LinkListQuery{
+		CreatedFrom: input.CreatedFrom,
+		CreatedTo:   input.CreatedTo,
+		IsActive:    input.IsActive,
+		Domain:      input.Domain,
+		Search:      input.Search,
+		SortBy:      input.SortBy,
+		Limit:       input.Limit,
+	}
+
+	switch query.SortBy {
+	case "":
+		query.SortBy = repository.SortByCreated
+	case repository.SortByCreated, repository.SortByClicks:
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListQuery, input.SortBy)
+	}
+	switch input.Order {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		return nil, fmt.Errorf("%w: unknown order %q", ErrInvalidListQuery, input.Order)
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultPageSize
+	}
+	if query.Limit > maxPageSize {
+		query.Limit = maxPageSize
+	}
+	// Un curseur n'est valable que pour le tri et les filtres de la liste qui l'a produit.
+	position := pageCursor{Sort: query.SortBy, Ascending: query.Ascending, Filters: filtersKey(query)}
+	if input.Cursor != "" {
+		cursor, err := decodeCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != position.Sort || cursor.Ascending != position.Ascending || cursor.Filters != position.Filters {
+			return nil, fmt.Errorf("%w: cursor does not match the requested sort, order or filters", ErrInvalidListQuery)
+		}
+		query.After = &cursor.LinkCursor
+	}
+
+	// Demande un élément de plus pour savoir s'il existe une page suivante.
+	pageSize := query.Limit
+	query.Limit++
+	links, err := s.linkRepo.ListLinks(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	page := &LinkPage{Links: links}
+	if len(links) > pageSize {
+		page.Links = links[:pageSize]
+		last := page.Links[pageSize-1]
+		position.LinkCursor = repository.LinkCursor{ClickCount: last.ClickCount, ID: last.ID}
+		page.NextCursor = encodeCursor(position)
+	}
+	return page, nil
+}
+
+// UpdateLink modifie l'URL de destination, l'état d'activation et/ou le comportement de secours d'un lien.
+func (s *LinkService) UpdateLink(ctx context.Context, shortCode string, input UpdateLinkInput) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	// L'état constaté par le moniteur concerne l'ancienne URL longue : il est réinitialisé si elle change.
+	destinationChanged := input.LongURL != nil && *input.LongURL != link.LongURL
+	if input.LongURL != nil {
+		link.LongURL = *input.LongURL
+	}
+	if input.IsActive != nil {
+		link.IsActive = *input.IsActive
+	}
+	if input.FallbackURL != nil {
+		link.FallbackURL = *input.FallbackURL
+	}
+	if input.FailoverPolicy != nil {
+		link.FailoverPolicy = *input.FailoverPolicy
+	}
+	if input.FallbackURL != nil || input.FailoverPolicy != nil {
+		if err := ValidateFailover(link.FailoverPolicy, link.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+	update := s.linkRepo.UpdateLink
+	if destinationChanged {
+		update = s.linkRepo.UpdateLinkDestination
+	}
+	if err := update(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to update link: %w", err)
+	}
+	return link, nil
+}
+
+// SetLinkActive active ou désactive un lien sans toucher à son historique.
+func (s *LinkService) SetLinkActive(ctx context.Context, shortCode string, active bool) (*models.Link, error) {
+	return s.UpdateLink(ctx, shortCode, UpdateLinkInput{IsActive: &active})
+}
+
+// DeleteLink supprime logiquement un lien ; son historique de clics est conservé.
+func (s *LinkService) DeleteLink(ctx context.Context, shortCode string) error {
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return fmt.Errorf("failed to get link: %w", err)
+	}
+	if err := s.linkRepo.DeleteLink(ctx, link); err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	return nil
+}
+
+// RestoreLink restaure un lien précédemment supprimé.
+func (s *LinkService) RestoreLink(ctx context.Context, shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetDeletedLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted link: %w", err)
+	}
+	if err := s.linkRepo.RestoreLink(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to restore link: %w", err)
+	}
+	return link, nil
 }
 
 // GetLinkStats récupère les statistiques pour un lien donné.
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
+func (s *LinkService) GetLinkStats(ctx context.Context, shortCode string) (*models.Link, *LinkStats, error) {
 	// Récupère le lien.
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get link: %w", err)
+		return nil, nil, fmt.Errorf("failed to get link: %w", err)
 	}
 	// Compte les clics.
-	clickCount, err := s.clickRepo.CountClicksByLinkID(link.ID)
+	clickCount, err := s.clickRepo.CountClicksByLinkID(ctx, link.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+	stats := &LinkStats{
+		TotalClicks: clickCount,
+		Breakdowns:  make(map[string][]models.BreakdownEntry, len(statsDimensions)),
+	}
+	// Compte les visiteurs uniques et ne détaille que les derniers jours.
+	days, approximate, err := s.countUniqueVisitors(ctx, link.ID, clickCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count unique visitors: %w", err)
+	}
+	for _, day := range days {
+		stats.UniqueVisitors += day.Visitors
+	}
+	stats.UniqueVisitorsApproximate = approximate
+	stats.UniqueVisitorsByDay = days[max(0, len(days)-uniqueVisitorsDays):]
+	// Ventile les clics selon chaque dimension.
+	for _, dimension := range statsDimensions {
+		entries, err := s.clickRepo.CountClicksByDimension(ctx, link.ID, dimension, breakdownLimit)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to break down clicks by %s: %w", dimension, err)
+		}
+		stats.Breakdowns[dimension] = entries
+	}
+	return link, stats, nil
+}
+
+// CheckLinkAvailability vérifie qu'un lien peut encore être suivi au regard de son activation,
+// de son expiration et de son budget de clics. Les clics encore en attente dans les workers ne sont pas comptés.
+func (s *LinkService) CheckLinkAvailability(ctx context.Context, link *models.Link) error {
+	if !link.IsActive {
+		return ErrLinkDisabled
+	}
+	if link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt) {
+		return ErrLinkExpired
+	}
+	if link.MaxClicks > 0 {
+		clickCount, err := s.clickRepo.CountClicksByLinkID(ctx, link.ID)
+		if err != nil {
+			return fmt.Errorf("failed to count clicks: %w", err)
+		}
+		if clickCount >= link.MaxClicks {
+			return ErrLinkExhausted
+		}
+	}
+	return nil
+}
+
+// RedirectTarget renvoie l'URL vers laquelle rediriger les visiteurs d'un lien disponible, selon l'état de son URL longue
+// enregistré par le moniteur et sa politique de secours. Elle renvoie ErrDestinationUnavailable si le lien doit
+// afficher une page d'indisponibilité. Tant que l'état est inconnu, l'URL longue est utilisée.
+// L'URL de secours n'est pas vérifiée par le moniteur : elle doit pointer vers une page fiable.
+func (s *LinkService) RedirectTarget(link *models.Link) (string, error) {
+	if link.HealthStatus != models.LinkHealthDown {
+		return link.LongURL, nil
+	}
+	switch link.FailoverPolicy {
+	case models.FailoverRedirectFallback:
+		if link.FallbackURL != "" {
+			return link.FallbackURL, nil
+		}
+	case models.FailoverUnavailablePage:
+		return "", ErrDestinationUnavailable
+	}
+	return link.LongURL, nil
+}
+
+// ValidateFailover vérifie une politique de secours et l'URL de secours associée.
+// La politique redirect_fallback exige une URL de secours ; celle-ci doit être une URL http ou https absolue.
+func ValidateFailover(policy, fallbackURL string) error {
+	switch policy {
+	case models.FailoverKeep, models.FailoverUnavailablePage:
+	case models.FailoverRedirectFallback:
+		if fallbackURL == "" {
+			return fmt.Errorf("%w: policy %s requires a fallback url", ErrInvalidFailover, policy)
+		}
+	default:
+		return fmt.Errorf("%w: unknown policy %q (expected %s, %s or %s)", ErrInvalidFailover, policy,
+			models.FailoverKeep, models.FailoverRedirectFallback, models.FailoverUnavailablePage)
+	}
+	if fallbackURL != "" {
+		u, err := url.Parse(fallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: fallback url must be an absolute http or https url", ErrInvalidFailover)
+		}
+	}
+	return nil
+}
+
+// pageCursor est le contenu d'un curseur de pagination : la position du dernier lien renvoyé,
+// ainsi que le tri et l'empreinte des filtres de la liste parcourue.
+type pageCursor struct {
+	repository.LinkCursor
+	Sort      string `json:"s"`
+	Ascending bool   `json:"a,omitempty"`
+	Filters   string `json:"f,omitempty"`
+}
+
+// filtersKey renvoie une empreinte courte des filtres d'une liste, vide en l'absence de filtre.
+func filtersKey(query repository.LinkListQuery) string {
+	var parts []string
+	if query.CreatedFrom != nil {
+		parts = append(parts, "from="+query.CreatedFrom.UTC().Format(time.RFC3339Nano))
+	}
+	if query.CreatedTo != nil {
+		parts = append(parts, "to="+query.CreatedTo.UTC().Format(time.RFC3339Nano))
+	}
+	if query.IsActive != nil {
+		parts = append(parts, fmt.Sprintf("active=%t", *query.IsActive))
+	}
+	if query.Domain != "" {
+		parts = append(parts, "domain="+strings.ToLower(query.Domain))
+	}
+	if query.Search != "" {
+		parts = append(parts, "q="+query.Search)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+// encodeCursor sérialise un curseur de pagination en chaîne opaque.
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor relit un curseur produit par encodeCursor.
+func decodeCursor(raw string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count clicks: %w", err)
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 || cursor.Sort == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
 	}
-	return link, clickCount, nil
+	return &cursor, nil
 }
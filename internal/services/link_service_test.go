@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, cursor := range []pageCursor{
+		{LinkCursor: repository.LinkCursor{ID: 42}, Sort: repository.SortByCreated},
+		{LinkCursor: repository.LinkCursor{ClickCount: 1234, ID: 7}, Sort: repository.SortByClicks, Ascending: true, Filters: "abc"},
+	} {
+		raw := encodeCursor(cursor)
+		decoded, err := decodeCursor(raw)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", raw, err)
+		}
+		if *decoded != cursor {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", cursor, *decoded)
+		}
+	}
+}
+
+func TestCursorIsURLSafe(t *testing.T) {
+	raw := encodeCursor(pageCursor{LinkCursor: repository.LinkCursor{ClickCount: 1 << 30, ID: 1 << 31}, Sort: repository.SortByClicks})
+	if _, err := base64.RawURLEncoding.DecodeString(raw); err != nil {
+		t.Errorf("cursor %q is not unpadded base64url: %v", raw, err)
+	}
+}
+
+func TestDecodeCursorRejectsMalformedInput(t *testing.T) {
+	for _, raw := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"c":3,"s":"clicks"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":1}`)),
+		base64.StdEncoding.EncodeToString([]byte(`{"id":1,"s":"created"}`)),
+	} {
+		if _, err := decodeCursor(raw); !errors.Is(err, ErrInvalidListQuery) {
+			t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidListQuery", raw, err)
+		}
+	}
+}
+
+// pagedLinkRepository renvoie les liens demandés par ListLinks à partir d'une liste fixe triée par id décroissant.
+type pagedLinkRepository struct {
+	repository.LinkRepository
+	links []models.LinkWithClickCount
+}
+
+func (r *pagedLinkRepository) ListLinks(_ context.Context, query repository.LinkListQuery) ([]models.LinkWithClickCount, error) {
+	var page []models.LinkWithClickCount
+	for _, link := range r.links {
+		if query.After != nil && link.ID >= query.After.ID {
+			continue
+		}
+		if len(page) == query.Limit {
+			break
+		}
+		page = append(page, link)
+	}
+	return page, nil
+}
+
+func TestListLinksFollowsNextCursor(t *testing.T) {
+	repo := &pagedLinkRepository{}
+	for id := uint(5); id >= 1; id-- {
+		repo.links = append(repo.links, models.LinkWithClickCount{Link: models.Link{ID: id}})
+	}
+	service := NewLinkService(repo, nil)
+
+	var ids []uint
+	input := ListLinksInput{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages == 5 {
+			t.Fatal("pagination did not end")
+		}
+		page, err := service.ListLinks(context.Background(), input)
+		if err != nil {
+			t.Fatalf("ListLinks: %v", err)
+		}
+		for _, link := range page.Links {
+			ids = append(ids, link.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		input.Cursor = page.NextCursor
+	}
+
+	want := []uint{5, 4, 3, 2, 1}
+	if len(ids) != len(want) {
+		t.Fatalf("listed %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("listed %v, want %v", ids, want)
+		}
+	}
+}
+
+func TestListLinksRejectsCursorOfAnotherList(t *testing.T) {
+	repo := &pagedLinkRepository{}
+	for id := uint(5); id >= 1; id-- {
+		repo.links = append(repo.links, models.LinkWithClickCount{Link: models.Link{ID: id}})
+	}
+	service := NewLinkService(repo, nil)
+	active := true
+	first, err := service.ListLinks(context.Background(), ListLinksInput{Limit: 2, Domain: "example.com"})
+	if err != nil || first.NextCursor == "" {
+		t.Fatalf("ListLinks = %+v, %v, want a next cursor", first, err)
+	}
+
+	tests := []struct {
+		name  string
+		input ListLinksInput
+		valid bool
+	}{
+		{"same list", ListLinksInput{Domain: "example.com"}, true},
+		{"same domain in another case", ListLinksInput{Domain: "Example.COM"}, true},
+		{"other sort", ListLinksInput{Domain: "example.com", SortBy: repository.SortByClicks}, false},
+		{"other order", ListLinksInput{Domain: "example.com", Order: "asc"}, false},
+		{"other domain", ListLinksInput{Domain: "example.org"}, false},
+		{"extra filter", ListLinksInput{Domain: "example.com", IsActive: &active}, false},
+		{"no filter", ListLinksInput{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.Limit = 2
+			tt.input.Cursor = first.NextCursor
+			_, err := service.ListLinks(context.Background(), tt.input)
+			if tt.valid && err != nil {
+				t.Errorf("ListLinks: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidListQuery) {
+				t.Errorf("ListLinks error = %v, want ErrInvalidListQuery", err)
+			}
+		})
+	}
+}
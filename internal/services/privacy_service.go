@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// visitorHashPattern valide une empreinte de visiteur (SHA-256 hexadécimal).
+var visitorHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Bornes de la liste des audits d'effacement.
+const (
+	defaultErasureAuditLimit = 50
+	maxErasureAuditLimit     = 500
+)
+
+// ErasureRequest décrit une demande d'effacement des clics d'une personne.
+// Exactement un critère, IP ou VisitorHash, doit être renseigné.
+type ErasureRequest struct {
+	IP          string
+	VisitorHash string
+	// Actor identifie l'auteur de la suppression (opérateur, API...).
+	Actor string
+	// Reason est une référence libre, par exemple le numéro de la demande.
+	Reason string
+}
+
+// VisitorHashFunc calcule l'empreinte de visiteur d'un événement de clic, comme à l'ingestion.
+type VisitorHashFunc func(event models.ClickEvent) (string, error)
+
+// PrivacyService traite les demandes d'effacement des données de clics.
+type PrivacyService struct {
+	erasureRepo repository.ErasureRepository
+	anonymizer  *privacy.Anonymizer
+	clickSpool  *spool.Spool
+	visitorHash VisitorHashFunc
+}
+
+// NewPrivacyService crée une nouvelle instance de PrivacyService.
+// anonymizer doit être celui appliqué à l'ingestion pour retrouver les adresses enregistrées.
+// clickSpool, s'il n'est pas nil, contient des événements non encore enregistrés qui sont effacés aussi ;
+// visitorHash permet alors d'y retrouver ceux d'une empreinte de visiteur.
+func NewPrivacyService(erasureRepo repository.ErasureRepository, anonymizer *privacy.Anonymizer, clickSpool *spool.Spool, visitorHash VisitorHashFunc) *PrivacyService {
+	return &PrivacyService{
+		erasureRepo: erasureRepo,
+		anonymizer:  anonymizer,
+		clickSpool:  clickSpool,
+		visitorHash: visitorHash,
+	}
+}
+
+// EraseClicks supprime tous les clics correspondant à la demande, enregistrés ou en attente dans le spool,
+// et renvoie l'audit enregistré. Une adresse IP est recherchée sous sa forme complète et sous sa forme anonymisée :
+// avec la troncature, les clics des autres adresses du même préfixe sont donc également supprimés.
+func (s *PrivacyService) EraseClicks(ctx context.Context, req ErasureRequest) (*models.ErasureAudit, error) {
+	ip := strings.TrimSpace(req.IP)
+	visitorHash := strings.ToLower(strings.TrimSpace(req.VisitorHash))
+	if (ip == "") == (visitorHash == "") {
+		return nil, fmt.Errorf("%w: exactly one of ip or visitor hash is required", ErrInvalidErasureRequest)
+	}
+
+	audit := &models.ErasureAudit{Actor: req.Actor, Reason: req.Reason, AnonymizationMode: s.anonymizer.Mode()}
+	var values []string
+	var match func(models.ClickEvent) bool
+	if ip != "" {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, fmt.Errorf("%w: invalid ip address %q", ErrInvalidErasureRequest, ip)
+		}
+		audit.Criterion = models.ErasureByIP
+		values = s.anonymizer.StoredForms(parsed.String())
+		match = func(event models.ClickEvent) bool {
+			return slices.Contains(values, event.IPAddress) || slices.Contains(values, s.anonymizer.Anonymize(event.IPAddress))
+		}
+	} else {
+		if !visitorHashPattern.MatchString(visitorHash) {
+			return nil, fmt.Errorf("%w: invalid visitor hash", ErrInvalidErasureRequest)
+		}
+		audit.Criterion = models.ErasureByVisitorHash
+		values = []string{visitorHash}
+		match = func(event models.ClickEvent) bool {
+			if s.visitorHash == nil {
+				return false
+			}
+			eventHash, err := s.visitorHash(event)
+			return err == nil && eventHash == visitorHash
+		}
+	}
+
+	// Le spool est purgé d'abord : ses événements pourraient sinon être enregistrés après l'effacement en base.
+	if s.clickSpool != nil {
+		spooled, err := s.clickSpool.Erase(ctx, match)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase spooled clicks: %w", err)
+		}
+		audit.SpooledCount = spooled
+	}
+
+	if err := s.erasureRepo.EraseClicks(ctx, values, audit); err != nil {
+		return nil, fmt.Errorf("failed to erase clicks: %w", err)
+	}
+	return audit, nil
+}
+
+// ListErasures renvoie les derniers audits d'effacement.
+func (s *PrivacyService) ListErasures(ctx context.Context, limit int) ([]models.ErasureAudit, error) {
+	if limit <= 0 {
+		limit = defaultErasureAuditLimit
+	}
+	limit = min(limit, maxErasureAuditLimit)
+	return s.erasureRepo.ListErasureAudits(ctx, limit)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Intervalles d'agrégation acceptés pour les séries temporelles.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// rollupDayLayout est le format des journées (UTC) des agrégats de clics.
+const rollupDayLayout = "2006-01-02"
+
+// clickSlot est la granularité à laquelle les clics sont comptés en base avant d'être répartis dans les intervalles.
+// Les décalages des fuseaux horaires étant des multiples de 15 minutes, un créneau n'est jamais à cheval sur deux
+// intervalles, quel que soit le fuseau demandé.
+const clickSlot = 15 * time.Minute
+
+// maxTimeSeriesBuckets borne la taille d'une série pour éviter les requêtes démesurées.
+const maxTimeSeriesBuckets = 5000
+
+// TimeSeriesBucket représente le nombre de clics d'un intervalle commençant à Start.
+type TimeSeriesBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+// TimeSeries est la répartition temporelle des clics d'un lien.
+type TimeSeries struct {
+	Interval string    `json:"interval"`
+	Timezone string    `json:"timezone"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Total    int       `json:"total"`
+	// RolledUpClicks est la part du total issue des agrégats quotidiens : ces clics, dont l'heure
+	// n'est plus connue, sont comptés dans l'intervalle contenant le début de leur journée (UTC), ou from
+	// s'il est postérieur. Une journée que la plage ne couvre qu'en partie compte au prorata de la durée
+	// couverte, les clics étant supposés répartis uniformément sur la journée.
+	RolledUpClicks int                `json:"rolled_up_clicks"`
+	Buckets        []TimeSeriesBucket `json:"buckets"`
+}
+
+// ParseTimeBound lit une borne de date au format RFC3339 ou AAAA-MM-JJ, interprétée dans loc.
+func ParseTimeBound(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// GetLinkTimeSeries calcule le nombre de clics d'un lien par heure, jour ou semaine entre from et to.
+// Les intervalles sont alignés sur le fuseau loc ; les semaines commencent le lundi.
+func (s *LinkService) GetLinkTimeSeries(ctx context.Context, shortCode string, from, to time.Time, interval string, loc *time.Location) (*models.Link, *TimeSeries, error) {
+	if interval != IntervalHour && interval != IntervalDay && interval != IntervalWeek {
+		return nil, nil, fmt.Errorf("%w: unknown interval %q", ErrInvalidTimeRange, interval)
+	}
+	if !from.Before(to) {
+		return nil, nil, fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidTimeRange)
+	}
+
+	start := truncateToInterval(from.In(loc), interval)
+	var buckets []TimeSeriesBucket
+	for t := start; t.Before(to); t = nextInterval(t, interval) {
+		if len(buckets) == maxTimeSeriesBuckets {
+			return nil, nil, fmt.Errorf("%w: more than %d buckets requested", ErrInvalidTimeRange, maxTimeSeriesBuckets)
+		}
+		buckets = append(buckets, TimeSeriesBucket{Start: t})
+	}
+
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	slots, err := s.clickRepo.CountClicksBySlot(ctx, link.ID, from, to, clickSlot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count clicks: %w", err)
+	}
+
+	// Les créneaux sont triés : on avance dans les intervalles au fil des créneaux.
+	series := &TimeSeries{Interval: interval, Timezone: loc.String(), From: from, To: to, Buckets: buckets}
+	i := 0
+	for _, slot := range slots {
+		for i+1 < len(buckets) && !slot.Start.Before(buckets[i+1].Start) {
+			i++
+		}
+		buckets[i].Clicks += slot.Clicks
+		series.Total += slot.Clicks
+	}
+
+	// Ajoute les clics purgés, agrégés par jour.
+	rollups, err := s.clickRepo.GetRollups(ctx, link.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get click rollups: %w", err)
+	}
+	for _, rollup := range rollups {
+		dayStart, err := time.Parse(rollupDayLayout, rollup.Day)
+		if err != nil {
+			continue
+		}
+		clicks, start := rolledUpClicksInRange(rollup.Clicks, dayStart, from, to)
+		if clicks == 0 {
+			continue
+		}
+		i := sort.Search(len(buckets), func(k int) bool { return buckets[k].Start.After(start) }) - 1
+		if i < 0 {
+			continue
+		}
+		buckets[i].Clicks += clicks
+		series.Total += clicks
+		series.RolledUpClicks += clicks
+	}
+	return link, series, nil
+}
+
+// rolledUpClicksInRange renvoie la part des clics d'une journée agrégée commençant à dayStart comprise
+// dans [from, to), au prorata de la durée couverte, ainsi que le début de la partie couverte.
+func rolledUpClicksInRange(clicks int, dayStart, from, to time.Time) (int, time.Time) {
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	start, end := dayStart, dayEnd
+	if from.After(start) {
+		start = from
+	}
+	if to.Before(end) {
+		end = to
+	}
+	if !start.Before(end) {
+		return 0, start
+	}
+	covered := float64(end.Sub(start)) / float64(dayEnd.Sub(dayStart))
+	return int(math.Round(float64(clicks) * covered)), start
+}
+
+// truncateToInterval ramène t au début de l'intervalle qui le contient, dans son propre fuseau.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // Nombre de jours écoulés depuis lundi.
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextInterval renvoie le début de l'intervalle suivant, en tenant compte des changements d'heure.
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return t.Add(time.Hour)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// staticLinkRepository renvoie toujours le même lien.
+type staticLinkRepository struct {
+	repository.LinkRepository
+	link models.Link
+}
+
+func (r *staticLinkRepository) GetLinkByShortCode(context.Context, string) (*models.Link, error) {
+	link := r.link
+	return &link, nil
+}
+
+// fixedClickRepository renvoie des créneaux et des agrégats fixes.
+type fixedClickRepository struct {
+	repository.ClickRepository
+	slots   []models.ClickSlot
+	rollups []models.ClickRollup
+}
+
+func (r *fixedClickRepository) CountClicksBySlot(_ context.Context, _ uint, from, to time.Time, _ time.Duration) ([]models.ClickSlot, error) {
+	var slots []models.ClickSlot
+	for _, slot := range r.slots {
+		if !slot.Start.Before(from) && slot.Start.Before(to) {
+			slots = append(slots, slot)
+		}
+	}
+	return slots, nil
+}
+
+func (r *fixedClickRepository) GetRollups(context.Context, uint) ([]models.ClickRollup, error) {
+	return r.rollups, nil
+}
+
+func TestTimeSeriesCountsRolledUpDaysInRange(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	clicks := &fixedClickRepository{
+		// Le 1er octobre est agrégé ; des clics bruts restent le 2.
+		rollups: []models.ClickRollup{{LinkID: 1, Day: "2026-10-01", Clicks: 96}},
+		slots:   []models.ClickSlot{{Start: at("2026-10-02T10:00:00Z"), Clicks: 5}},
+	}
+	service := NewLinkService(&staticLinkRepository{link: models.Link{ID: 1}}, clicks)
+
+	tests := []struct {
+		name     string
+		from, to string
+		interval string
+		rolledUp int
+		total    int
+		bucket   string // Début de l'intervalle recevant les clics agrégés.
+	}{
+		{"whole day", "2026-10-01T00:00:00Z", "2026-10-03T00:00:00Z", IntervalDay, 96, 101, "2026-10-01T00:00:00Z"},
+		{"range starting at noon", "2026-10-01T12:00:00Z", "2026-10-03T00:00:00Z", IntervalDay, 48, 53, "2026-10-01T00:00:00Z"},
+		{"range ending at 6am", "2026-09-30T00:00:00Z", "2026-10-01T06:00:00Z", IntervalDay, 24, 24, "2026-10-01T00:00:00Z"},
+		{"hourly range inside the day", "2026-10-01T18:00:00Z", "2026-10-01T21:00:00Z", IntervalHour, 12, 12, "2026-10-01T18:00:00Z"},
+		{"range after the day", "2026-10-02T00:00:00Z", "2026-10-03T00:00:00Z", IntervalDay, 0, 5, ""},
+		{"range before the day", "2026-09-29T00:00:00Z", "2026-10-01T00:00:00Z", IntervalDay, 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, series, err := service.GetLinkTimeSeries(context.Background(), "abc", at(tt.from), at(tt.to), tt.interval, time.UTC)
+			if err != nil {
+				t.Fatalf("GetLinkTimeSeries: %v", err)
+			}
+			if series.RolledUpClicks != tt.rolledUp || series.Total != tt.total {
+				t.Errorf("rolled up = %d, total = %d, want %d and %d", series.RolledUpClicks, series.Total, tt.rolledUp, tt.total)
+			}
+			sum := 0
+			for _, bucket := range series.Buckets {
+				sum += bucket.Clicks
+				if tt.bucket != "" && bucket.Start.Equal(at(tt.bucket)) && bucket.Clicks < tt.rolledUp {
+					t.Errorf("bucket %s has %d click(s), want at least %d", tt.bucket, bucket.Clicks, tt.rolledUp)
+				}
+			}
+			if sum != series.Total {
+				t.Errorf("buckets sum to %d, want the total %d", sum, series.Total)
+			}
+		})
+	}
+}
+
+func TestTimeSeriesBucketsSlotsInTimezone(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	utc := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 25, hour, minute, 0, 0, time.UTC)
+	}
+	clicks := &fixedClickRepository{slots: []models.ClickSlot{
+		{Start: utc(0, 30), Clicks: 1},  // 02:30 CEST, 06:00 IST.
+		{Start: utc(1, 30), Clicks: 2},  // 02:30 CET après le passage à l'heure d'hiver, 07:00 IST.
+		{Start: utc(18, 15), Clicks: 4}, // 19:15 CET, 23:45 IST.
+		{Start: utc(18, 30), Clicks: 8}, // 19:30 CET, 00:00 IST le lendemain.
+	}}
+	service := NewLinkService(&staticLinkRepository{link: models.Link{ID: 1}}, clicks)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		interval string
+		loc      *time.Location
+		buckets  int
+		want     map[time.Time]int // Clics attendus par début d'intervalle, les autres étant vides.
+	}{
+		{
+			name: "days in a half-hour offset zone", interval: IntervalDay, loc: kolkata,
+			from: time.Date(2026, 10, 25, 0, 0, 0, 0, kolkata), to: time.Date(2026, 10, 27, 0, 0, 0, 0, kolkata),
+			buckets: 2,
+			want: map[time.Time]int{
+				time.Date(2026, 10, 25, 0, 0, 0, 0, kolkata): 7,
+				time.Date(2026, 10, 26, 0, 0, 0, 0, kolkata): 8,
+			},
+		},
+		{
+			name: "hours in a half-hour offset zone", interval: IntervalHour, loc: kolkata,
+			from: utc(0, 0), to: utc(2, 0),
+			buckets: 3, // 05:00, 06:00 et 07:00 IST, la plage commençant à 05:30 IST.
+			want: map[time.Time]int{
+				time.Date(2026, 10, 25, 6, 0, 0, 0, kolkata): 1,
+				time.Date(2026, 10, 25, 7, 0, 0, 0, kolkata): 2,
+			},
+		},
+		{
+			name: "day lasting 25 hours", interval: IntervalDay, loc: paris,
+			from: time.Date(2026, 10, 25, 0, 0, 0, 0, paris), to: time.Date(2026, 10, 26, 0, 0, 0, 0, paris),
+			buckets: 1,
+			want:    map[time.Time]int{time.Date(2026, 10, 25, 0, 0, 0, 0, paris): 15},
+		},
+		{
+			name: "repeated hour at the end of daylight saving time", interval: IntervalHour, loc: paris,
+			from: time.Date(2026, 10, 25, 0, 0, 0, 0, paris), to: time.Date(2026, 10, 26, 0, 0, 0, 0, paris),
+			buckets: 25,
+			want:    map[time.Time]int{utc(0, 0): 1, utc(1, 0): 2, utc(18, 0): 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, series, err := service.GetLinkTimeSeries(context.Background(), "abc", tt.from, tt.to, tt.interval, tt.loc)
+			if err != nil {
+				t.Fatalf("GetLinkTimeSeries: %v", err)
+			}
+			if len(series.Buckets) != tt.buckets {
+				t.Fatalf("%d bucket(s), want %d", len(series.Buckets), tt.buckets)
+			}
+			for _, bucket := range series.Buckets {
+				want := 0
+				for start, clicks := range tt.want {
+					if bucket.Start.Equal(start) {
+						want = clicks
+					}
+				}
+				if bucket.Clicks != want {
+					t.Errorf("bucket %s has %d click(s), want %d", bucket.Start, bucket.Clicks, want)
+				}
+				if bucket.Start.Location() != tt.loc {
+					t.Errorf("bucket %s is not in %s", bucket.Start, tt.loc)
+				}
+			}
+		})
+	}
+}
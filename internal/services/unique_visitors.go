@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/axellelanca/urlshortener/internal/hll"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// uniqueVisitorsDays est le nombre de jours détaillés dans les statistiques.
+const uniqueVisitorsDays = 30
+
+// SetApproximateUniques active l'estimation des visiteurs uniques pour les liens dépassant threshold clics,
+// à partir des sketches HyperLogLog enregistrés par les workers ; 0 désactive l'estimation.
+func (s *LinkService) SetApproximateUniques(threshold int, sketchRepo repository.VisitorSketchRepository) {
+	s.hllThreshold = threshold
+	s.sketchRepo = sketchRepo
+}
+
+// countUniqueVisitors renvoie les visiteurs uniques par jour d'un lien et indique s'il s'agit d'une estimation.
+func (s *LinkService) countUniqueVisitors(ctx context.Context, linkID uint, totalClicks int) ([]models.DailyUniqueVisitors, bool, error) {
+	if s.sketchRepo == nil || s.hllThreshold <= 0 || totalClicks <= s.hllThreshold {
+		days, err := s.clickRepo.CountUniqueVisitorsByDay(ctx, linkID)
+		return days, false, err
+	}
+
+	// Les sketches sont enregistrés à l'ingestion : seuls quelques kilo-octets par jour sont lus.
+	sketches, err := s.sketchRepo.GetVisitorSketches(ctx, linkID)
+	if err != nil {
+		return nil, true, err
+	}
+	visitors := make(map[string]int, len(sketches))
+	for _, stored := range sketches {
+		sketch, err := hll.FromRegisters(stored.Registers)
+		if err != nil {
+			return nil, true, err
+		}
+		visitors[stored.Day] = int(sketch.Estimate())
+	}
+
+	// Les jours agrégés avant l'activation des sketches reprennent le nombre calculé lors de l'agrégation.
+	rollups, err := s.clickRepo.GetRollups(ctx, linkID)
+	if err != nil {
+		return nil, true, err
+	}
+	for _, rollup := range rollups {
+		if _, ok := visitors[rollup.Day]; !ok {
+			visitors[rollup.Day] = rollup.Uniques
+		}
+	}
+
+	days := make([]models.DailyUniqueVisitors, 0, len(visitors))
+	for day, count := range visitors {
+		if count > 0 {
+			days = append(days, models.DailyUniqueVisitors{Day: day, Visitors: count})
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days, true, nil
+}
@@ -0,0 +1,144 @@
+package sinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// rotationLayout horodate les fichiers archivés lors d'une rotation.
+const rotationLayout = "20060102T150405.000000000"
+
+// FileSink écrit les clics en JSON Lines dans un fichier qui est archivé
+// dès qu'il dépasse une taille maximale.
+type FileSink struct {
+	path       string
+	maxBytes   int64 // 0 : pas de rotation.
+	maxBackups int   // 0 : conserve toutes les archives.
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink ouvre (ou crée) le fichier path en ajout.
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file sink requires a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create sink directory: %w", err)
+	}
+	s := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write ajoute une ligne JSON par clic, en archivant le fichier si nécessaire.
+func (s *FileSink) Write(clicks []models.Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("file sink %s is closed", s.path)
+	}
+	for _, click := range clicks {
+		line, err := json.Marshal(NewRecord(click))
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if s.maxBytes > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxBytes {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := s.file.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write to %s: %w", s.path, err)
+		}
+	}
+	return nil
+}
+
+// Close ferme le fichier courant.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// open ouvre le fichier courant en ajout et relève sa taille.
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat %s: %w", s.path, err)
+	}
+	s.file = f
+	s.size = info.Size()
+	return nil
+}
+
+// rotate archive le fichier courant sous un nom horodaté, en ouvre un nouveau
+// et supprime les archives les plus anciennes au-delà de maxBackups.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", s.path, err)
+	}
+	s.file = nil
+	base, ext := s.splitPath()
+	archive := fmt.Sprintf("%s-%s%s", base, time.Now().UTC().Format(rotationLayout), ext)
+	if err := os.Rename(s.path, archive); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", s.path, err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.pruneBackups()
+}
+
+// pruneBackups ne conserve que les maxBackups archives les plus récentes.
+func (s *FileSink) pruneBackups() error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+	base, ext := s.splitPath()
+	archives, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	// Les noms horodatés se trient chronologiquement.
+	sort.Strings(archives)
+	for len(archives) > s.maxBackups {
+		if err := os.Remove(archives[0]); err != nil {
+			return fmt.Errorf("failed to remove old archive %s: %w", archives[0], err)
+		}
+		archives = archives[1:]
+	}
+	return nil
+}
+
+// splitPath sépare le chemin du fichier courant de son extension.
+func (s *FileSink) splitPath() (string, string) {
+	ext := filepath.Ext(s.path)
+	return strings.TrimSuffix(s.path, ext), ext
+}
@@ -0,0 +1,130 @@
+// Package sinks exporte les clics enregistrés par les workers vers des destinations externes
+// (fichier JSON Lines, webhook HTTP, sortie standard), en plus de la base SQLite.
+package sinks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Types de sinks reconnus dans la configuration.
+const (
+	TypeFile    = "file"
+	TypeWebhook = "webhook"
+	TypeStdout  = "stdout"
+)
+
+// Sink reçoit les lots de clics écrits par les workers.
+type Sink interface {
+	// Write exporte un lot de clics. Elle ne doit pas conserver la slice reçue.
+	Write(clicks []models.Click) error
+	// Close vide les clics en attente et libère les ressources du sink.
+	Close() error
+}
+
+// Record est la représentation exportée d'un clic enrichi.
+type Record struct {
+	ID             uint      `json:"id"`
+	LinkID         uint      `json:"link_id"`
+	Timestamp      time.Time `json:"timestamp"`
+	UserAgent      string    `json:"user_agent"`
+	IPAddress      string    `json:"ip_address"`
+	Browser        string    `json:"browser"`
+	BrowserVersion string    `json:"browser_version"`
+	OS             string    `json:"os"`
+	DeviceType     string    `json:"device_type"`
+	IsBot          bool      `json:"is_bot"`
+	Referrer       string    `json:"referrer"`
+	ReferrerHost   string    `json:"referrer_host"`
+	Country        string    `json:"country"`
+	Region         string    `json:"region"`
+	City           string    `json:"city"`
+	VisitorHash    string    `json:"visitor_hash"`
+}
+
+// NewRecord convertit un clic en enregistrement exporté.
+func NewRecord(click models.Click) Record {
+	return Record{
+		ID:             click.ID,
+		LinkID:         click.LinkID,
+		Timestamp:      click.Timestamp,
+		UserAgent:      click.UserAgent,
+		IPAddress:      click.IPAddress,
+		Browser:        click.Browser,
+		BrowserVersion: click.BrowserVersion,
+		OS:             click.OS,
+		DeviceType:     click.DeviceType,
+		IsBot:          click.IsBot,
+		Referrer:       click.Referrer,
+		ReferrerHost:   click.ReferrerHost,
+		Country:        click.Country,
+		Region:         click.Region,
+		City:           click.City,
+		VisitorHash:    click.VisitorHash,
+	}
+}
+
+// Multi diffuse chaque lot à plusieurs sinks. L'échec de l'un n'empêche pas l'écriture dans les autres.
+type Multi []Sink
+
+// Write écrit le lot dans chaque sink et renvoie l'ensemble des erreurs rencontrées.
+func (m Multi) Write(clicks []models.Click) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Write(clicks); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close ferme chaque sink et renvoie l'ensemble des erreurs rencontrées.
+func (m Multi) Close() error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FromConfig construit les sinks décrits dans la configuration, combinés dans un Multi.
+// Les sinks déjà ouverts sont refermés si l'un d'eux ne peut pas être créé.
+func FromConfig(cfgs []config.SinkConfig) (Multi, error) {
+	sinks := make(Multi, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		sink, err := newSink(cfg)
+		if err != nil {
+			sinks.Close()
+			return nil, fmt.Errorf("sink #%d (%s): %w", i+1, cfg.Type, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// newSink crée un sink selon son type.
+func newSink(cfg config.SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case TypeFile:
+		return NewFileSink(cfg.Path, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+	case TypeWebhook:
+		return NewWebhookSink(cfg.URL, WebhookOptions{
+			BatchSize:     cfg.BatchSize,
+			FlushInterval: time.Duration(cfg.FlushIntervalMs) * time.Millisecond,
+			Timeout:       time.Duration(cfg.TimeoutMs) * time.Millisecond,
+			MaxRetries:    cfg.MaxRetries,
+			QueueSize:     cfg.QueueSize,
+			Headers:       cfg.Headers,
+		})
+	case TypeStdout:
+		return NewStdoutSink(), nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
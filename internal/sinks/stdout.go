@@ -0,0 +1,44 @@
+package sinks
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// WriterSink écrit les clics en JSON Lines dans un io.Writer.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink crée un sink écrivant dans w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewStdoutSink crée un sink écrivant sur la sortie standard.
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink(os.Stdout)
+}
+
+// Write écrit une ligne JSON par clic.
+func (s *WriterSink) Write(clicks []models.Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	enc := json.NewEncoder(s.w)
+	for _, click := range clicks {
+		if err := enc.Encode(NewRecord(click)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ne ferme pas le writer sous-jacent, qui appartient à l'appelant.
+func (s *WriterSink) Close() error {
+	return nil
+}
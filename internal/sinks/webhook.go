@@ -0,0 +1,188 @@
+package sinks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Valeurs par défaut du sink webhook.
+const (
+	defaultWebhookBatchSize     = 500
+	defaultWebhookFlushInterval = 5 * time.Second
+	defaultWebhookTimeout       = 10 * time.Second
+	defaultWebhookQueueSize     = 10000
+	webhookRetryBaseDelay       = 500 * time.Millisecond
+)
+
+// ErrQueueFull est renvoyée quand des clics n'ont pas pu être placés dans la file d'un sink asynchrone.
+var ErrQueueFull = errors.New("sink queue is full")
+
+// WebhookOptions paramètre un WebhookSink.
+type WebhookOptions struct {
+	// BatchSize est le nombre maximal de clics envoyés par requête.
+	BatchSize int
+	// FlushInterval est le délai maximal avant l'envoi des clics accumulés.
+	FlushInterval time.Duration
+	// Timeout borne chaque requête HTTP.
+	Timeout time.Duration
+	// MaxRetries est le nombre de nouvelles tentatives après un échec (0 : aucune).
+	MaxRetries int
+	// QueueSize est le nombre de clics en attente d'envoi au-delà duquel les nouveaux clics sont écartés.
+	QueueSize int
+	// Headers sont ajoutés à chaque requête (ex: Authorization).
+	Headers map[string]string
+}
+
+// WebhookSink envoie les clics par lots, sous forme de tableau JSON, dans des requêtes POST.
+// Les envois ont lieu dans une goroutine dédiée pour ne pas ralentir les workers.
+type WebhookSink struct {
+	url    string
+	opts   WebhookOptions
+	client *http.Client
+	queue  chan Record
+	done   chan struct{}
+}
+
+// NewWebhookSink crée un sink envoyant les clics à url et démarre sa goroutine d'envoi.
+func NewWebhookSink(url string, opts WebhookOptions) (*WebhookSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook sink requires a url")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultWebhookBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultWebhookFlushInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultWebhookTimeout
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultWebhookQueueSize
+	}
+	s := &WebhookSink{
+		url:    url,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		queue:  make(chan Record, opts.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write place les clics dans la file d'envoi sans bloquer. Les clics qui n'y tiennent pas sont écartés.
+func (s *WebhookSink) Write(clicks []models.Click) error {
+	dropped := 0
+	for _, click := range clicks {
+		select {
+		case s.queue <- NewRecord(click):
+		default:
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		return fmt.Errorf("webhook %s: %d click(s) dropped: %w", s.url, dropped, ErrQueueFull)
+	}
+	return nil
+}
+
+// Close envoie les clics restants puis arrête la goroutine d'envoi.
+// Aucun appel à Write ne doit avoir lieu après Close.
+func (s *WebhookSink) Close() error {
+	close(s.queue)
+	<-s.done
+	return nil
+}
+
+// run accumule les clics de la file et les envoie dès que BatchSize est atteint ou que FlushInterval s'est écoulé.
+func (s *WebhookSink) run() {
+	defer close(s.done)
+
+	batch := make([]Record, 0, s.opts.BatchSize)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case record, ok := <-s.queue:
+			if !ok {
+				s.send(batch)
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= s.opts.BatchSize {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.send(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// send poste un lot en réessayant avec un délai croissant. Les réponses 4xx, hormis 429,
+// ne sont pas réessayées. Le lot est abandonné après la dernière tentative.
+func (s *WebhookSink) send(batch []Record) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		slog.Error("webhook sink failed to encode clicks", "count", len(batch), logging.Err(err))
+		return
+	}
+
+	delay := webhookRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.post(body)
+		if err == nil {
+			return
+		}
+		if !retryable || attempt >= s.opts.MaxRetries {
+			slog.Error("webhook sink dropped clicks", "count", len(batch), "attempts", attempt+1, logging.Err(err))
+			return
+		}
+		slog.Warn("webhook sink attempt failed, retrying", "attempt", attempt+1, "retry_in", delay, logging.Err(err))
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post effectue une requête et indique si un échec mérite une nouvelle tentative.
+func (s *WebhookSink) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,561 @@
+// Package spool fournit une file d'attente sur disque, en ajout seul, pour les événements de clic
+// qui ne peuvent pas être placés dans le channel des workers.
+//
+// Les événements sont écrits en JSON Lines dans des fichiers segments numérotés. Le segment actif
+// est scellé avant chaque rejeu : seuls des segments scellés sont relus, puis supprimés une fois
+// tous leurs événements acquittés par les workers (ClickEvent.Ack), c'est-à-dire enregistrés en base.
+// Les événements d'une personne peuvent en être effacés sur demande (Erase), car ils contiennent
+// son adresse IP complète.
+//
+// Durabilité : un événement ajouté survit à l'arrêt brutal du processus, mais n'est forcé sur disque
+// (fsync) qu'au scellement de son segment ou au plus tard après l'intervalle de synchronisation.
+// Une panne du système pendant cet intervalle peut faire perdre les derniers événements ajoutés.
+//
+// Livraison : au moins une fois. Un arrêt normal (Close) ne conserve que les événements non acquittés
+// du segment en cours de rejeu ; après un arrêt brutal, ce segment est rejoué en entier au démarrage
+// suivant et ses événements déjà enregistrés sont alors dupliqués.
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Préfixe et extension des fichiers segments.
+const (
+	segmentPrefix = "segment-"
+	segmentExt    = ".jsonl"
+	maxLineBytes  = 1 << 20
+)
+
+// roomPollInterval est le délai entre deux vérifications de la place disponible dans le channel.
+const roomPollInterval = 20 * time.Millisecond
+
+// ErrClosed est renvoyée par Append après la fermeture du spool.
+var ErrClosed = errors.New("spool closed")
+
+// errReplayInterrupted indique qu'un rejeu a été interrompu avant l'acquittement de tous les événements du segment.
+var errReplayInterrupted = errors.New("spool replay interrupted")
+
+// Spool est une file d'attente d'événements de clic persistée sur disque.
+type Spool struct {
+	dir             string
+	maxSegmentBytes int64
+
+	// segments sérialise les opérations sur les segments scellés (rejeu, effacement) ; canal de capacité 1.
+	// Un rejeu interrompu le conserve jusqu'à Close.
+	segments chan struct{}
+
+	mu          sync.Mutex
+	current     *os.File
+	currentSize int64
+	dirty       bool // Le segment actif contient des écritures pas encore forcées sur disque.
+	nextSeq     uint64
+	pending     int64          // Nombre d'événements écrits et pas encore acquittés.
+	interrupted *segmentReplay // Rejeu interrompu en attente de Close.
+	closed      bool
+
+	syncInterval time.Duration
+	stopSync     chan struct{} // Fermé par Close pour arrêter la synchronisation périodique.
+	closeOnce    sync.Once
+}
+
+// Open ouvre (ou crée) le spool situé dans dir. Les segments laissés par une exécution
+// précédente seront rejoués par Replay. Le segment actif est forcé sur disque toutes les syncInterval,
+// ou à chaque ajout si syncInterval vaut 0. Close doit être appelée pour arrêter la synchronisation.
+func Open(dir string, maxSegmentBytes int64, syncInterval time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	s := &Spool{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		segments:        make(chan struct{}, 1),
+		syncInterval:    max(syncInterval, 0),
+		stopSync:        make(chan struct{}),
+	}
+
+	segments, err := s.listSegments()
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segments {
+		s.nextSeq = max(s.nextSeq, seg.seq+1)
+		lines, err := countLines(seg.path)
+		if err != nil {
+			return nil, err
+		}
+		s.pending += lines
+	}
+	if s.pending > 0 {
+		slog.Info("spooled click events from a previous run will be replayed", "events", s.pending, "segments", len(segments))
+	}
+	if s.syncInterval > 0 {
+		go s.syncLoop()
+	}
+	return s, nil
+}
+
+// Append ajoute un événement à la fin du segment actif, en ouvrant un nouveau segment si besoin.
+func (s *Spool) Append(event models.ClickEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode click event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.current != nil && s.currentSize+int64(len(line)) > s.maxSegmentBytes {
+		if err := s.sealLocked(); err != nil {
+			return err
+		}
+	}
+	if s.current == nil {
+		path := filepath.Join(s.dir, fmt.Sprintf("%s%020d%s", segmentPrefix, s.nextSeq, segmentExt))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		s.nextSeq++
+		s.current = f
+		s.currentSize = 0
+	}
+
+	n, err := s.current.Write(line)
+	s.currentSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write spool segment: %w", err)
+	}
+	s.pending++
+	s.dirty = true
+	if s.syncInterval == 0 {
+		return s.syncLocked()
+	}
+	return nil
+}
+
+// Pending renvoie le nombre d'événements en attente de rejeu ou d'acquittement.
+func (s *Spool) Pending() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Replay rejoue les segments dans le channel des workers jusqu'à l'annulation de ctx.
+// Les événements ne sont envoyés que lorsque le channel est à moins de la moitié de sa capacité,
+// afin de laisser de la place au trafic en direct.
+func (s *Spool) Replay(ctx context.Context, events chan<- models.ClickEvent, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.replayOnce(ctx, events); err != nil && ctx.Err() == nil {
+			slog.Error("spool replay failed", logging.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close arrête la synchronisation périodique et scelle le segment actif ; les ajouts suivants renvoient ErrClosed.
+// Si un rejeu a été interrompu, son segment est réécrit avec les seuls événements non acquittés.
+// Les événements non rejoués restent sur disque pour le démarrage suivant. Close doit être appelée après l'arrêt
+// de Replay et, pour éviter les doublons, après que les workers ont traité les événements reçus.
+func (s *Spool) Close() error {
+	s.closeOnce.Do(func() { close(s.stopSync) })
+	s.mu.Lock()
+	s.closed = true
+	err := s.sealLocked()
+	interrupted := s.interrupted
+	s.interrupted = nil
+	s.mu.Unlock()
+
+	if interrupted != nil {
+		if finishErr := s.finishReplay(interrupted); err == nil {
+			err = finishErr
+		}
+		s.unlockSegments()
+	}
+	return err
+}
+
+// syncLoop force le segment actif sur disque toutes les syncInterval, jusqu'à Close.
+func (s *Spool) syncLoop() {
+	ticker := time.NewTicker(s.syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopSync:
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		err := s.syncLocked()
+		s.mu.Unlock()
+		if err != nil {
+			slog.Error("failed to sync spool segment", logging.Err(err))
+		}
+	}
+}
+
+// syncLocked force sur disque les écritures du segment actif. Le verrou doit être détenu.
+func (s *Spool) syncLocked() error {
+	if s.current == nil || !s.dirty {
+		return nil
+	}
+	if err := s.current.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// replayOnce scelle le segment actif puis rejoue tous les segments scellés, du plus ancien au plus récent.
+func (s *Spool) replayOnce(ctx context.Context, events chan<- models.ClickEvent) error {
+	if !hasRoom(events) {
+		return nil
+	}
+
+	s.mu.Lock()
+	if err := s.sealLocked(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	segments, err := s.listSegments()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		if err := s.lockSegments(ctx); err != nil {
+			return err
+		}
+		err := s.replaySegment(ctx, seg, events)
+		if errors.Is(err, errReplayInterrupted) {
+			// Le segment reste réservé jusqu'à Close, qui y conservera les événements non acquittés.
+			return nil
+		}
+		s.unlockSegments()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Erase supprime du spool les événements pour lesquels match renvoie true et renvoie leur nombre.
+// Le segment actif est scellé, puis chaque segment contenant de tels événements est réécrit sans eux.
+// Erase attend que les événements du segment en cours de rejeu soient acquittés, ou l'annulation de ctx.
+func (s *Spool) Erase(ctx context.Context, match func(models.ClickEvent) bool) (int64, error) {
+	if err := s.lockSegments(ctx); err != nil {
+		return 0, err
+	}
+	defer s.unlockSegments()
+
+	s.mu.Lock()
+	if err := s.sealLocked(); err != nil {
+		s.mu.Unlock()
+		return 0, err
+	}
+	segments, err := s.listSegments()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int64
+	for _, seg := range segments {
+		events, err := readSegment(seg.path)
+		if err != nil {
+			return erased, err
+		}
+		kept := make([]models.ClickEvent, 0, len(events))
+		for _, event := range events {
+			if !match(event) {
+				kept = append(kept, event)
+			}
+		}
+		if len(kept) == len(events) {
+			continue
+		}
+		if len(kept) == 0 {
+			err = os.Remove(seg.path)
+		} else {
+			err = s.requeue(seg, kept)
+		}
+		if err != nil {
+			return erased, fmt.Errorf("failed to erase spooled events: %w", err)
+		}
+		removed := int64(len(events) - len(kept))
+		erased += removed
+		s.mu.Lock()
+		s.pending -= removed
+		s.mu.Unlock()
+	}
+	return erased, nil
+}
+
+// lockSegments réserve l'accès aux segments scellés, ou renvoie l'erreur de ctx s'il est annulé avant.
+func (s *Spool) lockSegments(ctx context.Context) error {
+	select {
+	case s.segments <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unlockSegments libère l'accès aux segments scellés.
+func (s *Spool) unlockSegments() {
+	<-s.segments
+}
+
+// segmentReplay suit l'acquittement des événements d'un segment en cours de rejeu.
+type segmentReplay struct {
+	seg    segment
+	events []models.ClickEvent
+
+	mu       sync.Mutex
+	acked    []bool
+	ackCount int
+	finished bool          // Rejeu clos par Close : les acquittements suivants sont ignorés.
+	done     chan struct{} // Fermé quand tous les événements sont acquittés.
+}
+
+// ack renvoie la fonction d'acquittement de l'événement i.
+func (s *Spool) ack(r *segmentReplay, i int) func() {
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.finished || r.acked[i] {
+			return
+		}
+		r.acked[i] = true
+		r.ackCount++
+		// done est fermé avant la mise à jour de pending : un Pending() nul garantit la suppression du segment.
+		if r.ackCount == len(r.events) {
+			close(r.done)
+		}
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+	}
+}
+
+// replaySegment transmet les événements d'un segment puis le supprime une fois tous ses événements acquittés.
+// Si ctx est annulé avant, le rejeu est confié à Close et errReplayInterrupted est renvoyée.
+func (s *Spool) replaySegment(ctx context.Context, seg segment, events chan<- models.ClickEvent) error {
+	batch, err := readSegment(seg.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Segment entièrement effacé depuis la liste des segments.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return s.removeSegment(seg)
+	}
+
+	r := &segmentReplay{seg: seg, events: batch, acked: make([]bool, len(batch)), done: make(chan struct{})}
+	for i, event := range batch {
+		for !hasRoom(events) {
+			select {
+			case <-ctx.Done():
+				return s.interrupt(r)
+			case <-time.After(roomPollInterval):
+			}
+		}
+		event.Ack = s.ack(r, i)
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return s.interrupt(r)
+		}
+	}
+	select {
+	case <-r.done:
+	default:
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return s.interrupt(r)
+		}
+	}
+
+	if err := s.removeSegment(seg); err != nil {
+		return err
+	}
+	slog.Info("replayed spooled click events", "events", len(batch), "segment", filepath.Base(seg.path))
+	return nil
+}
+
+// interrupt confie un rejeu interrompu à Close.
+func (s *Spool) interrupt(r *segmentReplay) error {
+	s.mu.Lock()
+	s.interrupted = r
+	s.mu.Unlock()
+	return errReplayInterrupted
+}
+
+// finishReplay clôt un rejeu interrompu : son segment est supprimé si tous ses événements ont été acquittés,
+// réécrit avec les événements restants sinon.
+func (s *Spool) finishReplay(r *segmentReplay) error {
+	r.mu.Lock()
+	r.finished = true
+	remaining := make([]models.ClickEvent, 0, len(r.events)-r.ackCount)
+	for i, event := range r.events {
+		if !r.acked[i] {
+			remaining = append(remaining, event)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(remaining) == 0 {
+		return s.removeSegment(r.seg)
+	}
+	return s.requeue(r.seg, remaining)
+}
+
+// removeSegment supprime un segment entièrement traité.
+func (s *Spool) removeSegment(seg segment) error {
+	if err := os.Remove(seg.path); err != nil {
+		return fmt.Errorf("failed to remove replayed spool segment: %w", err)
+	}
+	return nil
+}
+
+// requeue remplace un segment partiellement acquitté ou effacé par un segment ne contenant que les événements restants.
+func (s *Spool) requeue(seg segment, remaining []models.ClickEvent) error {
+	tmp := seg.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, event := range remaining {
+		if err := enc.Encode(event); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to rewrite spool segment: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite spool segment: %w", err)
+	}
+	return os.Rename(tmp, seg.path)
+}
+
+// sealLocked ferme le segment actif pour qu'il puisse être rejoué. Le verrou doit être détenu.
+func (s *Spool) sealLocked() error {
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Sync()
+	if closeErr := s.current.Close(); err == nil {
+		err = closeErr
+	}
+	s.current = nil
+	s.currentSize = 0
+	s.dirty = false
+	if err != nil {
+		return fmt.Errorf("failed to seal spool segment: %w", err)
+	}
+	return nil
+}
+
+// segment décrit un fichier segment et son numéro d'ordre.
+type segment struct {
+	path string
+	seq  uint64
+}
+
+// listSegments renvoie les segments présents sur disque, triés par numéro d'ordre.
+func (s *Spool) listSegments() ([]segment, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool segments: %w", err)
+	}
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{path: filepath.Join(s.dir, name), seq: seq})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].seq < segments[j].seq })
+	return segments, nil
+}
+
+// readSegment lit les événements d'un segment. Une ligne illisible (ex: écriture interrompue
+// par un arrêt brutal) est ignorée.
+func readSegment(path string) ([]models.ClickEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	var events []models.ClickEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		var event models.ClickEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			slog.Warn("skipping unreadable spool line", "segment", filepath.Base(path), logging.Err(err))
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	return events, nil
+}
+
+// countLines compte les événements d'un segment.
+func countLines(path string) (int64, error) {
+	events, err := readSegment(path)
+	return int64(len(events)), err
+}
+
+// hasRoom indique si le channel est à moins de la moitié de sa capacité.
+func hasRoom(events chan<- models.ClickEvent) bool {
+	return len(events) < max(cap(events)/2, 1)
+}
@@ -0,0 +1,316 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// openTestSpool ouvre un spool dans dir.
+func openTestSpool(t *testing.T, dir string, maxSegmentBytes int64) *Spool {
+	t.Helper()
+	s, err := Open(dir, maxSegmentBytes, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// appendEvents ajoute des événements portant les adresses IP fournies.
+func appendEvents(t *testing.T, s *Spool, ips ...string) {
+	t.Helper()
+	for i, ip := range ips {
+		event := models.ClickEvent{LinkID: uint(i + 1), Timestamp: time.Now(), IPAddress: ip}
+		if err := s.Append(event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+// drain rejoue le spool dans un channel, acquitte les événements reçus et les renvoie une fois le spool vide.
+func drain(t *testing.T, s *Spool, capacity int) []models.ClickEvent {
+	t.Helper()
+	events := make(chan models.ClickEvent, capacity)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Replay(ctx, events, 10*time.Millisecond)
+	}()
+
+	var received []models.ClickEvent
+	deadline := time.After(5 * time.Second)
+	for s.Pending() > 0 || len(events) > 0 {
+		select {
+		case event := <-events:
+			event.Ack()
+			received = append(received, event)
+		case <-deadline:
+			t.Fatalf("replay did not finish, %d event(s) pending", s.Pending())
+		}
+	}
+	cancel()
+	<-done
+	return received
+}
+
+func TestReplayDeliversEventsInOrderAcrossSegments(t *testing.T) {
+	// Des segments de 200 octets ne contiennent qu'un ou deux événements.
+	s := openTestSpool(t, t.TempDir(), 200)
+	appendEvents(t, s, "203.0.113.1", "203.0.113.2", "203.0.113.3", "203.0.113.4", "203.0.113.5")
+	if pending := s.Pending(); pending != 5 {
+		t.Fatalf("Pending() = %d, want 5", pending)
+	}
+
+	received := drain(t, s, 10)
+	if len(received) != 5 {
+		t.Fatalf("replayed %d event(s), want 5", len(received))
+	}
+	for i, event := range received {
+		if event.LinkID != uint(i+1) {
+			t.Errorf("event %d has link %d, want %d", i, event.LinkID, i+1)
+		}
+	}
+	segments, err := s.listSegments()
+	if err != nil {
+		t.Fatalf("listSegments: %v", err)
+	}
+	if len(segments) != 0 {
+		t.Errorf("%d segment(s) left after replay, want 0", len(segments))
+	}
+}
+
+func TestOpenResumesEventsLeftByPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	previous := openTestSpool(t, dir, 1<<20)
+	appendEvents(t, previous, "203.0.113.1", "203.0.113.2")
+	if err := previous.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s := openTestSpool(t, dir, 1<<20)
+	if pending := s.Pending(); pending != 2 {
+		t.Fatalf("Pending() after restart = %d, want 2", pending)
+	}
+	appendEvents(t, s, "198.51.100.7")
+	if pending := s.Pending(); pending != 3 {
+		t.Errorf("Pending() = %d, want 3", pending)
+	}
+
+	// Les événements de l'exécution précédente sont rejoués avant les nouveaux.
+	received := drain(t, s, 10)
+	var ips []string
+	for _, event := range received {
+		ips = append(ips, event.IPAddress)
+	}
+	want := []string{"203.0.113.1", "203.0.113.2", "198.51.100.7"}
+	if len(ips) != len(want) || ips[0] != want[0] || ips[1] != want[1] || ips[2] != want[2] {
+		t.Errorf("replayed %v, want %v", ips, want)
+	}
+}
+
+func TestOpenSkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	previous := openTestSpool(t, dir, 1<<20)
+	appendEvents(t, previous, "203.0.113.1")
+	if err := previous.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// Simule une écriture interrompue par un arrêt brutal.
+	segments, err := previous.listSegments()
+	if err != nil || len(segments) != 1 {
+		t.Fatalf("listSegments = %v, %v, want one segment", segments, err)
+	}
+	f, err := os.OpenFile(segments[0].path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	f.WriteString(`{"link_id":2,"ip_addr`)
+	f.Close()
+
+	s := openTestSpool(t, dir, 1<<20)
+	if pending := s.Pending(); pending != 1 {
+		t.Errorf("Pending() = %d, want 1", pending)
+	}
+	if received := drain(t, s, 10); len(received) != 1 {
+		t.Errorf("replayed %d event(s), want 1", len(received))
+	}
+}
+
+func TestReplayKeepsSegmentUntilEventsAreAcked(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 1<<20)
+	appendEvents(t, s, "203.0.113.1", "203.0.113.2")
+
+	events := make(chan models.ClickEvent, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Replay(ctx, events, 10*time.Millisecond)
+	var received []models.ClickEvent
+	for len(received) < 2 {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(5 * time.Second):
+			t.Fatal("replay did not start")
+		}
+	}
+
+	// Les événements transmis mais pas encore enregistrés restent sur disque.
+	time.Sleep(50 * time.Millisecond)
+	if segments, _ := s.listSegments(); len(segments) != 1 {
+		t.Fatalf("%d segment(s) on disk before ack, want 1", len(segments))
+	}
+	if pending := s.Pending(); pending != 2 {
+		t.Errorf("Pending() = %d before ack, want 2", pending)
+	}
+
+	received[0].Ack()
+	received[0].Ack() // Un second acquittement est sans effet.
+	received[1].Ack()
+	deadline := time.After(5 * time.Second)
+	for {
+		segments, _ := s.listSegments()
+		if len(segments) == 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("segment not removed after every event was acked")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if pending := s.Pending(); pending != 0 {
+		t.Errorf("Pending() = %d after ack, want 0", pending)
+	}
+}
+
+func TestInterruptedReplayKeepsUnackedEvents(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1<<20)
+	appendEvents(t, s, "203.0.113.1", "203.0.113.2", "203.0.113.3", "203.0.113.4", "203.0.113.5")
+
+	// Un channel de capacité 2 n'accepte qu'un événement à la fois : le rejeu attend qu'il soit lu.
+	events := make(chan models.ClickEvent, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Replay(ctx, events, 10*time.Millisecond)
+	}()
+	var received []models.ClickEvent
+	for len(received) < 2 {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(5 * time.Second):
+			t.Fatal("replay did not start")
+		}
+	}
+	// Seul le premier événement est enregistré avant l'arrêt.
+	received[0].Ack()
+	cancel()
+	<-done
+
+	if pending := s.Pending(); pending != 4 {
+		t.Errorf("Pending() = %d after acking one event, want 4", pending)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// Un acquittement tardif, après Close, est ignoré : l'événement reste dans le spool.
+	received[1].Ack()
+
+	// Les événements non acquittés, transmis ou non, sont rejoués au redémarrage.
+	restarted := openTestSpool(t, dir, 1<<20)
+	if pending := restarted.Pending(); pending != 4 {
+		t.Errorf("Pending() after restart = %d, want 4", pending)
+	}
+	replayed := drain(t, restarted, 10)
+	if len(replayed) != 4 || replayed[0].IPAddress != "203.0.113.2" {
+		t.Errorf("replayed %+v after restart, want the 4 events from 203.0.113.2", replayed)
+	}
+}
+
+func TestAppendAfterCloseFails(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1<<20)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	err := s.Append(models.ClickEvent{LinkID: 1, Timestamp: time.Now()})
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Append after Close = %v, want ErrClosed", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentExt)); len(matches) != 0 {
+		t.Errorf("segments on disk = %v, want none", matches)
+	}
+}
+
+func TestCloseSyncsAndSealsActiveSegment(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1<<20)
+	appendEvents(t, s, "203.0.113.1")
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if s.current != nil {
+		t.Error("active segment still open after Close")
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentExt))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("segments on disk = %v, %v, want one", matches, err)
+	}
+	// Close peut être appelée plusieurs fois.
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestEraseRemovesMatchingEvents(t *testing.T) {
+	s := openTestSpool(t, t.TempDir(), 1<<20)
+	appendEvents(t, s, "203.0.113.1", "198.51.100.7", "203.0.113.1")
+
+	erased, err := s.Erase(context.Background(), func(event models.ClickEvent) bool {
+		return event.IPAddress == "203.0.113.1"
+	})
+	if err != nil {
+		t.Fatalf("Erase: %v", err)
+	}
+	if erased != 2 {
+		t.Errorf("erased = %d, want 2", erased)
+	}
+	if pending := s.Pending(); pending != 1 {
+		t.Errorf("Pending() = %d, want 1", pending)
+	}
+
+	received := drain(t, s, 10)
+	if len(received) != 1 || received[0].IPAddress != "198.51.100.7" {
+		t.Errorf("replayed %+v, want only the event of 198.51.100.7", received)
+	}
+}
+
+func TestEraseRemovesFullyErasedSegments(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestSpool(t, dir, 1<<20)
+	appendEvents(t, s, "203.0.113.1")
+
+	if _, err := s.Erase(context.Background(), func(models.ClickEvent) bool { return true }); err != nil {
+		t.Fatalf("Erase: %v", err)
+	}
+	segments, err := s.listSegments()
+	if err != nil {
+		t.Fatalf("listSegments: %v", err)
+	}
+	if len(segments) != 0 {
+		t.Errorf("%d segment(s) left after erasing every event, want 0", len(segments))
+	}
+	if pending := s.Pending(); pending != 0 {
+		t.Errorf("Pending() = %d, want 0", pending)
+	}
+}
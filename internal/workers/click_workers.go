@@ -1,38 +1,234 @@
 package workers
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/sinks"
 )
 
+// Valeurs par défaut du regroupement des clics.
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// Enricher complète un clic à partir de son événement d'origine avant sa persistance.
+type Enricher interface {
+	Enrich(click *models.Click, event models.ClickEvent) error
+}
+
+// Publisher reçoit chaque clic enrichi dès son traitement, avant son écriture en base.
+// Publish ne doit jamais bloquer.
+type Publisher interface {
+	Publish(click models.Click)
+}
+
+// Options paramètre le pool de workers de clics.
+type Options struct {
+	// BatchSize est le nombre de clics accumulés déclenchant une écriture groupée.
+	BatchSize int
+	// FlushInterval est le délai maximal avant l'écriture des clics accumulés.
+	FlushInterval time.Duration
+	// Enrichers sont appliqués dans l'ordre fourni à chaque clic.
+	Enrichers []Enricher
+	// Sink reçoit chaque lot de clics après son écriture en base ; nil pour ne rien exporter.
+	Sink sinks.Sink
+	// Publisher diffuse les clics en temps réel ; nil pour ne pas les diffuser.
+	Publisher Publisher
+	// VisitorSketches reçoit les empreintes de visiteurs des clics enregistrés ; nil pour ne pas tenir de sketches.
+	VisitorSketches repository.VisitorSketchRepository
+}
+
+// Pool regroupe les workers qui enregistrent les événements de clic par lots.
+type Pool struct {
+	clickEventsChan <-chan models.ClickEvent
+	clickRepo       repository.ClickRepository
+	opts            Options
+	metrics         flushMetrics
+	sketchMu        sync.Mutex // Sérialise la mise à jour des sketches, lus puis réécrits.
+	wg              sync.WaitGroup
+}
+
 // StartClickWorkers lance un pool de workers pour traiter les événements de clic.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
+// Chaque worker écrit ses clics dès que BatchSize est atteint ou que FlushInterval s'est écoulé.
+func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository, opts Options) *Pool {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	p := &Pool{
+		clickEventsChan: clickEventsChan,
+		clickRepo:       clickRepo,
+		opts:            opts,
+	}
+
+	slog.Info("starting click workers",
+		"workers", workerCount, "batch_size", opts.BatchSize, "flush_interval", opts.FlushInterval)
 	for i := 0; i < workerCount; i++ {
-		go clickWorker(clickEventsChan, clickRepo)
+		p.wg.Add(1)
+		go p.clickWorker()
+	}
+	return p
+}
+
+// Metrics renvoie les statistiques cumulées des écritures groupées.
+func (p *Pool) Metrics() FlushMetricsSnapshot {
+	return p.metrics.snapshot()
+}
+
+// Wait attend que les workers aient écrit leurs derniers clics après la fermeture du channel.
+// Elle renvoie l'erreur de ctx si le délai expire avant.
+func (p *Pool) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// clickWorker accumule les événements de clic et les persiste par lots jusqu'à la fermeture du channel.
+func (p *Pool) clickWorker() {
+	defer p.wg.Done()
+
+	batch := make([]models.Click, 0, p.opts.BatchSize)
+	// acks contient les acquittements des événements du lot, appelés une fois le lot traité.
+	var acks []func()
+	flush := func() {
+		p.flush(batch)
+		batch = batch[:0]
+		for _, ack := range acks {
+			ack()
+		}
+		acks = acks[:0]
+	}
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-p.clickEventsChan:
+			if !ok {
+				// Le channel est fermé : écrit les derniers clics avant de s'arrêter.
+				flush()
+				return
+			}
+			p.metrics.receive()
+			click := p.buildClick(event)
+			if p.opts.Publisher != nil {
+				p.opts.Publisher.Publish(click)
+			}
+			batch = append(batch, click)
+			if event.Ack != nil {
+				acks = append(acks, event.Ack)
+			}
+			if len(batch) >= p.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				flush()
+			}
+		}
 	}
 }
 
-// clickWorker traite les événements de clic de manière asynchrone.
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan {
-		// Convertit l'événement en modèle Click.
-		click := &models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
+// buildClick convertit un événement en clic enrichi.
+func (p *Pool) buildClick(event models.ClickEvent) models.Click {
+	click := models.Click{
+		LinkID:    event.LinkID,
+		Timestamp: event.Timestamp,
+		UserAgent: event.UserAgent,
+		IPAddress: event.IPAddress,
+		Referrer:  event.Referrer,
+	}
+	// Enrichit le clic avec les informations extraites du User-Agent et du référent.
+	parseUserAgent(&click)
+	click.ReferrerHost = referrerHost(event.Referrer)
+
+	// Un enrichissement en échec n'empêche pas l'enregistrement du clic.
+	for _, enricher := range p.opts.Enrichers {
+		if err := enricher.Enrich(&click, event); err != nil {
+			slog.Warn("failed to enrich click", logging.KeyLinkID, event.LinkID, logging.Err(err))
 		}
+	}
+	return click
+}
 
-		// Persiste le clic en base de données.
-		err := clickRepo.CreateClick(click)
-		if err != nil {
-			log.Printf("ERROR: Failed to save click for LinkID %d: %v",
-				event.LinkID, err)
-		} else {
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+// flush persiste un lot de clics. En cas d'échec du lot, chaque clic est réessayé
+// individuellement pour qu'une ligne invalide ne fasse pas perdre tout le lot.
+func (p *Pool) flush(batch []models.Click) {
+	if len(batch) == 0 {
+		return
+	}
+	// Les écritures se poursuivent pendant l'arrêt : elles ne dépendent d'aucune requête.
+	ctx := context.Background()
+	start := time.Now()
+	err := p.clickRepo.CreateClicks(ctx, batch)
+	latency := time.Since(start)
+	p.metrics.record(len(batch), latency, err)
+	metrics.ClickBatchSize.Observe(float64(len(batch)))
+	metrics.ClickFlushDuration.Observe(latency.Seconds())
+	if err == nil {
+		p.metrics.persist(len(batch))
+		metrics.ClicksPersistedTotal.Add(float64(len(batch)))
+		slog.Debug("flushed clicks", "count", len(batch), "latency", latency)
+		p.recordSketches(ctx, batch)
+		p.export(batch)
+		return
+	}
+
+	metrics.WorkerInsertErrorsTotal.WithLabelValues("batch").Inc()
+	slog.Error("failed to save click batch, retrying one by one", "count", len(batch), logging.Err(err))
+	// Seuls les clics enregistrés sont exportés : les sinks reflètent la base.
+	saved := make([]models.Click, 0, len(batch))
+	for i := range batch {
+		if err := p.clickRepo.CreateClick(ctx, &batch[i]); err != nil {
+			metrics.WorkerInsertErrorsTotal.WithLabelValues("single").Inc()
+			slog.Error("failed to save click", logging.KeyLinkID, batch[i].LinkID, logging.Err(err))
+			continue
 		}
+		p.metrics.persist(1)
+		metrics.ClicksPersistedTotal.Inc()
+		saved = append(saved, batch[i])
+	}
+	p.recordSketches(ctx, saved)
+	p.export(saved)
+}
+
+// recordSketches ajoute les visiteurs des clics enregistrés aux sketches quotidiens. Un échec n'affecte que l'estimation.
+func (p *Pool) recordSketches(ctx context.Context, batch []models.Click) {
+	if p.opts.VisitorSketches == nil || len(batch) == 0 {
+		return
+	}
+	p.sketchMu.Lock()
+	defer p.sketchMu.Unlock()
+	if err := p.opts.VisitorSketches.RecordVisitorSketches(ctx, batch); err != nil {
+		slog.Error("failed to update visitor sketches", "count", len(batch), logging.Err(err))
+	}
+}
+
+// export transmet un lot de clics au sink configuré. Un échec d'export n'affecte pas la base.
+func (p *Pool) export(batch []models.Click) {
+	if p.opts.Sink == nil || len(batch) == 0 {
+		return
+	}
+	if err := p.opts.Sink.Write(batch); err != nil {
+		p.metrics.sinkFailure()
+		slog.Error("failed to export clicks to sinks", "count", len(batch), logging.Err(err))
 	}
 }
@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoEnricher renseigne le pays, la région et la ville d'un clic à partir d'une base
+// MaxMind (.mmdb) locale. Aucune requête réseau n'est effectuée.
+type GeoEnricher struct {
+	reader  *geoip2.Reader
+	hasCity bool // Faux pour les bases ne contenant que les pays (ex: GeoLite2-Country).
+}
+
+// NewGeoEnricher ouvre la base MaxMind située à path.
+func NewGeoEnricher(path string) (*GeoEnricher, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+	}
+	return &GeoEnricher{
+		reader:  reader,
+		hasCity: strings.Contains(reader.Metadata().DatabaseType, "City"),
+	}, nil
+}
+
+// Enrich renseigne la géolocalisation du clic ; les adresses inconnues de la base sont ignorées.
+func (g *GeoEnricher) Enrich(click *models.Click, event models.ClickEvent) error {
+	ip := net.ParseIP(event.IPAddress)
+	if ip == nil {
+		return nil
+	}
+
+	if !g.hasCity {
+		record, err := g.reader.Country(ip)
+		if err != nil {
+			return fmt.Errorf("GeoIP lookup failed: %w", err)
+		}
+		click.Country = record.Country.IsoCode
+		return nil
+	}
+
+	record, err := g.reader.City(ip)
+	if err != nil {
+		return fmt.Errorf("GeoIP lookup failed: %w", err)
+	}
+	click.Country = record.Country.IsoCode
+	if len(record.Subdivisions) > 0 {
+		click.Region = record.Subdivisions[0].Names["en"]
+	}
+	click.City = record.City.Names["en"]
+	return nil
+}
+
+// Close libère la base MaxMind.
+func (g *GeoEnricher) Close() error {
+	return g.reader.Close()
+}
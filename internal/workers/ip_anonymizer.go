@@ -0,0 +1,23 @@
+package workers
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/privacy"
+)
+
+// IPAnonymizer remplace l'adresse IP du clic par sa forme anonymisée.
+// Il doit être le dernier enricher : les autres lisent l'adresse complète depuis l'événement.
+type IPAnonymizer struct {
+	anonymizer *privacy.Anonymizer
+}
+
+// NewIPAnonymizer crée une nouvelle instance de IPAnonymizer.
+func NewIPAnonymizer(anonymizer *privacy.Anonymizer) *IPAnonymizer {
+	return &IPAnonymizer{anonymizer: anonymizer}
+}
+
+// Enrich anonymise l'adresse IP du clic.
+func (a *IPAnonymizer) Enrich(click *models.Click, event models.ClickEvent) error {
+	click.IPAddress = a.anonymizer.Anonymize(event.IPAddress)
+	return nil
+}
@@ -0,0 +1,94 @@
+package workers
+
+import (
+	"sync"
+	"time"
+)
+
+// flushMetrics cumule les mesures des écritures groupées de clics.
+type flushMetrics struct {
+	mu            sync.Mutex
+	flushes       int64
+	failedFlushes int64
+	clicks        int64
+	received      int64 // Événements lus dans le channel.
+	persisted     int64 // Clics effectivement enregistrés en base.
+	sinkFailures  int64 // Lots dont l'export vers les sinks a échoué.
+	maxBatchSize  int
+	totalLatency  time.Duration
+	maxLatency    time.Duration
+	lastLatency   time.Duration
+}
+
+// FlushMetricsSnapshot est une copie des mesures des écritures groupées à un instant donné.
+type FlushMetricsSnapshot struct {
+	Flushes       int64         `json:"flushes"`
+	FailedFlushes int64         `json:"failed_flushes"`
+	Clicks        int64         `json:"clicks"`
+	Received      int64         `json:"received"`
+	Persisted     int64         `json:"persisted"`
+	SinkFailures  int64         `json:"sink_failures"`
+	AvgBatchSize  float64       `json:"avg_batch_size"`
+	MaxBatchSize  int           `json:"max_batch_size"`
+	AvgLatency    time.Duration `json:"avg_latency"`
+	MaxLatency    time.Duration `json:"max_latency"`
+	LastLatency   time.Duration `json:"last_latency"`
+}
+
+// receive comptabilise un événement lu dans le channel.
+func (m *flushMetrics) receive() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received++
+}
+
+// persist comptabilise des clics effectivement enregistrés en base.
+func (m *flushMetrics) persist(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.persisted += int64(count)
+}
+
+// sinkFailure comptabilise un lot dont l'export a échoué.
+func (m *flushMetrics) sinkFailure() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sinkFailures++
+}
+
+// record enregistre le résultat d'une écriture groupée.
+func (m *flushMetrics) record(batchSize int, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushes++
+	if err != nil {
+		m.failedFlushes++
+	}
+	m.clicks += int64(batchSize)
+	m.maxBatchSize = max(m.maxBatchSize, batchSize)
+	m.totalLatency += latency
+	m.maxLatency = max(m.maxLatency, latency)
+	m.lastLatency = latency
+}
+
+// snapshot renvoie une copie cohérente des mesures.
+func (m *flushMetrics) snapshot() FlushMetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := FlushMetricsSnapshot{
+		Flushes:       m.flushes,
+		FailedFlushes: m.failedFlushes,
+		Clicks:        m.clicks,
+		Received:      m.received,
+		Persisted:     m.persisted,
+		SinkFailures:  m.sinkFailures,
+		MaxBatchSize:  m.maxBatchSize,
+		MaxLatency:    m.maxLatency,
+		LastLatency:   m.lastLatency,
+	}
+	if m.flushes > 0 {
+		s.AvgBatchSize = float64(m.clicks) / float64(m.flushes)
+		s.AvgLatency = m.totalLatency / time.Duration(m.flushes)
+	}
+	return s
+}
@@ -0,0 +1,20 @@
+package workers
+
+import (
+	"net/url"
+	"strings"
+)
+
+// referrerHost extrait le domaine normalisé d'un référent : en minuscules, sans port ni préfixe "www.".
+// Un référent vide ou illisible donne une chaîne vide.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.TrimPrefix(host, "www.")
+}
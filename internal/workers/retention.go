@@ -0,0 +1,81 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// RetentionJob agrège par jour puis purge les clics bruts plus anciens que la fenêtre de rétention.
+type RetentionJob struct {
+	clickRepo     repository.ClickRepository
+	retentionDays int
+	interval      time.Duration
+}
+
+// NewRetentionJob crée un job conservant retentionDays jours (UTC) de clics bruts, exécuté toutes les interval.
+func NewRetentionJob(clickRepo repository.ClickRepository, retentionDays int, interval time.Duration) *RetentionJob {
+	return &RetentionJob{
+		clickRepo:     clickRepo,
+		retentionDays: retentionDays,
+		interval:      interval,
+	}
+}
+
+// Start exécute le job immédiatement puis périodiquement, jusqu'à l'annulation de ctx.
+func (j *RetentionJob) Start(ctx context.Context) {
+	slog.Info("starting click retention job", "raw_click_days", j.retentionDays, "interval", j.interval)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := j.RunOnce(ctx); err != nil {
+			slog.Error("click retention job failed", logging.Err(err))
+		} else if purged > 0 {
+			slog.Info("click retention job rolled up raw clicks", "purged", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce agrège et purge les clics bruts antérieurs à la fenêtre de rétention, une journée par transaction
+// pour ne pas bloquer longtemps l'écriture des nouveaux clics. Elle renvoie le nombre de clics purgés.
+func (j *RetentionJob) RunOnce(ctx context.Context) (int64, error) {
+	cutoff := retentionCutoff(time.Now(), j.retentionDays)
+	var total int64
+	for ctx.Err() == nil {
+		oldest, err := j.clickRepo.OldestClickBefore(ctx, cutoff)
+		if err != nil {
+			return total, fmt.Errorf("failed to find oldest raw click: %w", err)
+		}
+		if oldest == nil {
+			break
+		}
+		dayStart := oldest.UTC().Truncate(24 * time.Hour)
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		purged, err := j.clickRepo.RollupClicks(ctx, dayStart, dayEnd)
+		if err != nil {
+			return total, fmt.Errorf("failed to roll up clicks of %s: %w", dayStart.Format("2006-01-02"), err)
+		}
+		if purged == 0 {
+			// Évite de boucler indéfiniment sur un clic que l'intervalle du jour ne couvre pas.
+			return total, fmt.Errorf("no raw click purged for %s", dayStart.Format("2006-01-02"))
+		}
+		total += purged
+	}
+	return total, nil
+}
+
+// retentionCutoff renvoie le début (UTC) du plus ancien jour dont les clics bruts sont conservés.
+func retentionCutoff(now time.Time, retentionDays int) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	return today.AddDate(0, 0, -retentionDays)
+}
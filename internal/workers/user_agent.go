@@ -0,0 +1,57 @@
+package workers
+
+import (
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/mssola/useragent"
+)
+
+// Classes d'appareil attribuées aux clics.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceUnknown = "unknown"
+)
+
+// botMarkers complète la détection de robots de la bibliothèque pour les clients sans navigateur.
+var botMarkers = []string{"bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headless"}
+
+// parseUserAgent renseigne le navigateur, le système, la classe d'appareil et l'indicateur robot d'un clic.
+func parseUserAgent(click *models.Click) {
+	if click.UserAgent == "" {
+		click.DeviceType = DeviceUnknown
+		return
+	}
+
+	ua := useragent.New(click.UserAgent)
+	click.Browser, click.BrowserVersion = ua.Browser()
+	click.OS = ua.OSInfo().Name
+	click.IsBot = ua.Bot() || containsAny(strings.ToLower(click.UserAgent), botMarkers)
+	click.DeviceType = deviceType(ua, click.UserAgent)
+}
+
+// deviceType déduit la classe d'appareil ; les tablettes Android n'annoncent pas "Mobile".
+func deviceType(ua *useragent.UserAgent, raw string) string {
+	lower := strings.ToLower(raw)
+	switch {
+	case strings.Contains(lower, "ipad"), strings.Contains(lower, "tablet"),
+		strings.Contains(lower, "android") && !strings.Contains(lower, "mobile"):
+		return DeviceTablet
+	case ua.Mobile():
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// containsAny indique si s contient l'une des sous-chaînes fournies.
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
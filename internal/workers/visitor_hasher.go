@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/logging"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// saltSize est la taille en octets des sels quotidiens.
+const saltSize = 32
+
+// VisitorHasher calcule l'empreinte d'un visiteur à partir de son IP et de son User-Agent,
+// salée par un sel aléatoire qui change chaque jour (UTC). Le couple IP/User-Agent n'est jamais
+// conservé sous forme d'empreinte réversible : une fois le sel supprimé, l'empreinte ne peut plus être recalculée.
+// Le User-Agent brut n'est pas enregistré avec l'empreinte : seules ses informations extraites le sont.
+type VisitorHasher struct {
+	saltRepo repository.VisitorSaltRepository
+	mu       sync.Mutex
+	salts    map[string][]byte // Sels en cache, indexés par jour.
+}
+
+// NewVisitorHasher crée une nouvelle instance de VisitorHasher.
+func NewVisitorHasher(saltRepo repository.VisitorSaltRepository) *VisitorHasher {
+	return &VisitorHasher{
+		saltRepo: saltRepo,
+		salts:    make(map[string][]byte),
+	}
+}
+
+// Enrich renseigne l'empreinte de visiteur du clic et efface son User-Agent brut,
+// déjà analysé par le worker.
+func (h *VisitorHasher) Enrich(click *models.Click, event models.ClickEvent) error {
+	visitorHash, err := h.Hash(event)
+	if err != nil {
+		return err
+	}
+	click.VisitorHash = visitorHash
+	click.UserAgent = ""
+	return nil
+}
+
+// Hash renvoie l'empreinte de visiteur d'un événement de clic.
+func (h *VisitorHasher) Hash(event models.ClickEvent) (string, error) {
+	salt, err := h.saltFor(event.Timestamp)
+	if err != nil {
+		return "", fmt.Errorf("failed to get visitor salt: %w", err)
+	}
+	sum := sha256.New()
+	sum.Write(salt)
+	sum.Write([]byte(event.IPAddress))
+	sum.Write([]byte{0})
+	sum.Write([]byte(event.UserAgent))
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// saltFor renvoie le sel du jour du clic. Au changement de jour, les sels de plus d'un jour
+// sont supprimés ; celui de la veille est conservé pour les clics encore en file d'attente.
+func (h *VisitorHasher) saltFor(at time.Time) ([]byte, error) {
+	day := at.UTC().Format(time.DateOnly)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if salt, ok := h.salts[day]; ok {
+		return salt, nil
+	}
+
+	candidate := make([]byte, saltSize)
+	if _, err := rand.Read(candidate); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	salt, err := h.saltRepo.GetOrCreateSalt(ctx, day, candidate)
+	if err != nil {
+		return nil, err
+	}
+	h.salts[day] = salt
+
+	// Rotation : oublie et supprime les sels antérieurs à la veille.
+	yesterday := at.UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	for cachedDay := range h.salts {
+		if cachedDay < yesterday {
+			delete(h.salts, cachedDay)
+		}
+	}
+	if err := h.saltRepo.DeleteSaltsBefore(ctx, yesterday); err != nil {
+		slog.Warn("failed to delete expired visitor salts", logging.Err(err))
+	}
+	return salt, nil
+}